@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"workshop-starter/pkg/tui"
+)
+
+func (a *app) browse(args []string, stdin io.Reader) error {
+	flags := a.flagSet("browse", "[-open command]")
+	opener := flags.String("open", "", "command to open URLs with, $BROWSER or the platform opener by default")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	tty, ok := stdin.(*os.File)
+	if !ok {
+		return errors.New("browse needs a terminal")
+	}
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return fmt.Errorf("browse needs a terminal: %v", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return err
+	}
+	defer stty(tty, state)
+
+	width, height := 80, 24
+	if size, err := stty(tty, "size"); err == nil {
+		fmt.Sscan(size, &height, &width)
+	}
+
+	// Use the alternate screen so that the shell comes back untouched.
+	fmt.Fprint(a.stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(a.stdout, "\x1b[?25h\x1b[?1049l")
+
+	app := tui.New(a.client, tui.WithSize(width, height), tui.WithOpener(tui.OpenCommand(*opener)))
+
+	return app.Run(tty, a.stdout)
+}
+
+// stty runs stty on the terminal and returns its output.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"workshop-starter/pkg/hn"
+)
+
+func (a *app) maxItem(args []string) error {
+	flags := a.flagSet("maxitem", "")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	id, err := a.client.MaxItem()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.stdout, id)
+
+	return err
+}
+
+func (a *app) item(args []string) error {
+	flags := a.flagSet("item", "<id>")
+	if err := a.parse(flags, args, 1); err != nil {
+		return err
+	}
+	id, err := itemID(flags.Arg(0))
+	if err != nil {
+		return a.usageError(flags, "%v", err)
+	}
+
+	item, err := a.client.GetItem(id)
+	if err != nil {
+		return err
+	}
+	entry := hn.Entry{Item: item}
+	if item.IsPoll() {
+		poll, failures := hn.FetchPoll(a.client, item)
+		for _, f := range failures {
+			fmt.Fprintf(a.stderr, "quartz: skipping option %d of poll %d: %v\n", f.Id, item.Id, f.Err)
+		}
+		entry.Poll = &poll
+	}
+
+	return a.write([]hn.Entry{entry})
+}
+
+func (a *app) user(args []string) error {
+	flags := a.flagSet("user", "<name>")
+	if err := a.parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	user, err := a.client.GetUser(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if a.format == "json" || a.format == "jsonl" {
+		return json.NewEncoder(a.stdout).Encode(user)
+	}
+
+	_, err = fmt.Fprintf(a.stdout, "user: %s\ncreated: %s\nkarma: %d\nsubmitted: %d\n",
+		user.Id, time.Unix(user.Created, 0).UTC().Format(time.RFC3339), user.Karma, len(user.Submitted))
+	if err == nil && user.About != "" {
+		_, err = fmt.Fprintf(a.stdout, "about: %s\n", user.About)
+	}
+
+	return err
+}
+
+func (a *app) list(name string, args []string) error {
+	flags := a.flagSet(name, "[-n count]")
+	n := flags.Int("n", 30, "number of stories, 0 for the whole list")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	list := hn.StoryList(name)
+	if name == "jobs" {
+		list = hn.JobStories
+	}
+	ids, err := a.client.GetStories(list)
+	if err != nil {
+		return err
+	}
+	if *n > 0 && *n < len(ids) {
+		ids = ids[:*n]
+	}
+
+	return a.write(a.fetchAll(ids))
+}
+
+// fetchAll fetches items with up to a.concurrency requests in flight,
+// keeping the order of ids. Items that cannot be fetched are reported on
+// stderr and left out.
+func (a *app) fetchAll(ids []int) []hn.Entry {
+	items := make([]hn.Item, len(ids))
+	errs := make([]error, len(ids))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < a.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				items[i], errs[i] = a.client.GetItem(ids[i])
+			}
+		}()
+	}
+	for i := range ids {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var entries []hn.Entry
+	for i, item := range items {
+		if errs[i] != nil {
+			fmt.Fprintf(a.stderr, "quartz: skipping item %d: %v\n", ids[i], errs[i])
+			continue
+		}
+		entries = append(entries, hn.Entry{Item: item})
+	}
+
+	return entries
+}
+
+func (a *app) write(entries []hn.Entry) error {
+	encoder, err := a.encoder()
+	if err != nil {
+		return err
+	}
+	if err := encoder.Header(a.stdout); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if err := encoder.Encode(a.stdout, e, i); err != nil {
+			return err
+		}
+	}
+
+	return encoder.Footer(a.stdout, len(entries))
+}
+
+func (a *app) dump(args []string, stdin io.Reader) error {
+	flags := a.flagSet("dump", "[flags]")
+	n := flags.Int("n", 10, "number of matching items to write")
+	types := flags.String("type", "", "comma separated item types to keep")
+	minScore := flags.Int("min-score", 0, "minimum score")
+	top := flags.Int("top", 0, "write only the best k items of the scanned window")
+	sortKey := flags.String("sort", "score", "ranking for -top: score, time, comments or hotness")
+	since := flags.String("since", "", "dump the items posted from this time on (RFC 3339 or YYYY-MM-DD)")
+	until := flags.String("until", "", "dump the items posted before this time; requires -since")
+	ids := flags.String("ids", "", "read the item IDs from this file, - for stdin")
+	checkpoint := flags.String("checkpoint", "", "save progress to this file and resume from it; requires -o")
+	checkpointEvery := flags.Int("checkpoint-every", 100, "number of scanned IDs between checkpoints")
+	output := flags.String("o", "", "file to write to, stdout by default")
+	polls := flags.Bool("polls", false, "fetch poll options")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	encoder, err := a.encoder()
+	if err != nil {
+		return err
+	}
+	opts := []hn.DumpOption{
+		hn.WithEncoder(encoder),
+		hn.WithLogger(log.New(a.stderr, "quartz ", 0)),
+	}
+	var filter hn.Filter
+	if *types != "" {
+		filter.Types = strings.Split(*types, ",")
+	}
+	filter.MinScore = *minScore
+	if len(filter.Types) > 0 || filter.MinScore > 0 {
+		opts = append(opts, hn.WithFilter(filter))
+	}
+	if *top > 0 {
+		key, err := parseSortKey(*sortKey)
+		if err != nil {
+			return a.usageError(flags, "%v", err)
+		}
+		opts = append(opts, hn.WithTop(*top, key))
+	}
+	if *checkpoint != "" {
+		// Resuming rewinds the output, which a pipe or a file emptied by the
+		// shell cannot do.
+		if *output == "" {
+			return a.usageError(flags, "-checkpoint requires -o")
+		}
+		opts = append(opts, hn.WithCheckpoint(*checkpoint, *checkpointEvery))
+	}
+	if *polls {
+		opts = append(opts, hn.WithPollResults())
+	}
+
+	var dump *hn.Dump
+	switch {
+	case *since != "" && *ids != "":
+		return a.usageError(flags, "-since and -ids cannot be combined")
+	case *since != "":
+		from, err := parseTime(*since)
+		if err != nil {
+			return a.usageError(flags, "%v", err)
+		}
+		to := time.Now()
+		if *until != "" {
+			if to, err = parseTime(*until); err != nil {
+				return a.usageError(flags, "%v", err)
+			}
+		}
+		dump = hn.NewTimeDump(a.client, from, to, append(opts, hn.WithLimit(*n))...)
+	case *until != "":
+		return a.usageError(flags, "-until requires -since")
+	case *ids == "-":
+		dump = hn.NewDumpFrom(a.client, hn.IDReader(stdin), append(opts, hn.WithLimit(*n))...)
+	case *ids != "":
+		file, err := os.Open(*ids)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		dump = hn.NewDumpFrom(a.client, hn.IDReader(file), append(opts, hn.WithLimit(*n))...)
+	default:
+		dump = hn.NewDump(a.client, *n, opts...)
+	}
+
+	if *output == "" {
+		return dump.Dump(a.stdout)
+	}
+	f, err := openDumpOutput(*output, *checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := dump.Dump(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// openDumpOutput creates the output of a dump, or opens it as it is when
+// there is a checkpoint to resume from.
+func openDumpOutput(path, checkpoint string) (*os.File, error) {
+	if checkpoint != "" {
+		_, resuming, err := hn.LoadCheckpoint(checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		if resuming {
+			return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		}
+	}
+
+	return os.Create(path)
+}
+
+func (a *app) story(args []string) error {
+	flags := a.flagSet("story", "[flags] <id>")
+	depth := flags.Int("depth", 0, "maximum comment depth, 0 for no limit")
+	comments := flags.Int("comments", 0, "maximum number of comments, 0 for no limit")
+	thread := flags.Bool("thread", false, "when <id> is a comment, only fetch its thread")
+	width := flags.Int("width", 80, "column to wrap comments at")
+	color := flags.Bool("color", false, "colour the comment tree by depth")
+	if err := a.parse(flags, args, 1); err != nil {
+		return err
+	}
+	id, err := itemID(flags.Arg(0))
+	if err != nil {
+		return a.usageError(flags, "%v", err)
+	}
+
+	opts := []hn.StoryOption{hn.WithMaxDepth(*depth), hn.WithMaxComments(*comments)}
+	if *thread {
+		opts = append(opts, hn.WithThreadOnly())
+	}
+	story, err := hn.NewStoryBuilder(a.client, opts...).BuildFrom(id)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" || a.format == "jsonl" {
+		return json.NewEncoder(a.stdout).Encode(story)
+	}
+
+	return hn.TextRenderer{Width: *width, Color: *color}.Render(a.stdout, story)
+}
+
+func (a *app) epub(args []string) error {
+	flags := a.flagSet("epub", "[flags] <id>...")
+	output := flags.String("o", "", "file to write the book to, stdout by default")
+	title := flags.String("title", "", "title of the book")
+	depth := flags.Int("depth", 0, "maximum comment depth, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		return a.usageError(flags, "expected at least one story ID")
+	}
+
+	builder := hn.NewStoryBuilder(a.client, hn.WithMaxDepth(*depth))
+	var stories []hn.Story
+	for _, arg := range flags.Args() {
+		id, err := itemID(arg)
+		if err != nil {
+			return a.usageError(flags, "%v", err)
+		}
+		story, err := builder.Build(id)
+		if err != nil {
+			return err
+		}
+		stories = append(stories, story)
+	}
+
+	book := hn.EPUBWriter{Title: *title}
+	if *output == "" {
+		return book.Write(a.stdout, stories...)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := book.Write(f, stories...); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func itemID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid item ID %q", s)
+	}
+
+	return id, nil
+}
+
+func parseSortKey(s string) (hn.SortKey, error) {
+	switch s {
+	case "score":
+		return hn.SortByScore, nil
+	case "time":
+		return hn.SortByTime, nil
+	case "comments":
+		return hn.SortByComments, nil
+	case "hotness":
+		return hn.SortByHotness, nil
+	}
+
+	return 0, fmt.Errorf("unknown sort key %q", s)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,207 @@
+// Command quartz queries the Hacker News API from the command line.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+	"workshop-starter/pkg/hn"
+)
+
+// Exit codes. Scripts can tell a missing item or user from an API that
+// could not be reached.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitNetwork  = 4
+)
+
+const usage = `Usage: quartz [flags] <command> [arguments]
+
+Commands:
+  maxitem                 print the newest item ID
+  item <id>               print an item
+  user <name>             print a user
+  top|new|best|ask|show|jobs
+                          print the items of a story list
+  dump                    dump items walking down from the newest one
+  story <id>              print a story with its comments
+  epub <id>...            package stories with their comments as an EPUB book
+  browse                  browse the story lists in a full-screen terminal UI
+  site <store> <dir>      generate a static site from the items in a store
+  serve                   serve a cached JSON API in front of the upstream one
+
+Run quartz <command> -h for the flags of a command.
+
+Flags:
+`
+
+// errUsage marks errors caused by the command line rather than the API.
+var errUsage = errors.New("usage")
+
+// app holds what the global flags configure.
+type app struct {
+	client      *hn.HackerNewsClient
+	concurrency int
+	format      string
+	columns     []hn.Column
+	stdout      io.Writer
+	stderr      io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("quartz", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	baseURL := flags.String("url", hn.NewHTTPClient().BaseUrl, "base URL of the API")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request")
+	concurrency := flags.Int("concurrency", 8, "parallel requests for story lists")
+	format := flags.String("format", "legacy", "output format: legacy, csv, tsv, json, jsonl, markdown, rss or atom")
+	columns := flags.String("columns", "", "comma separated columns for structured formats")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() == 0 || *concurrency < 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	a := &app{
+		client:      &hn.HackerNewsClient{BaseUrl: *baseURL, HTTPClient: &http.Client{Timeout: *timeout}},
+		concurrency: *concurrency,
+		format:      *format,
+		stdout:      stdout,
+		stderr:      stderr,
+	}
+	if *columns != "" {
+		parsed, err := hn.ParseColumns(*columns)
+		if err != nil {
+			fmt.Fprintf(stderr, "quartz: %v\n", err)
+			return exitUsage
+		}
+		a.columns = parsed
+	}
+	if _, err := a.encoder(); err != nil {
+		fmt.Fprintf(stderr, "quartz: %v\n", err)
+		return exitUsage
+	}
+
+	name, rest := flags.Arg(0), flags.Args()[1:]
+	var err error
+	switch name {
+	case "maxitem":
+		err = a.maxItem(rest)
+	case "item":
+		err = a.item(rest)
+	case "user":
+		err = a.user(rest)
+	case "top", "new", "best", "ask", "show", "jobs":
+		err = a.list(name, rest)
+	case "dump":
+		err = a.dump(rest, stdin)
+	case "story":
+		err = a.story(rest)
+	case "epub":
+		err = a.epub(rest)
+	case "browse":
+		err = a.browse(rest, stdin)
+	case "site":
+		err = a.site(rest)
+	case "serve":
+		err = a.serve(rest)
+	default:
+		fmt.Fprintf(stderr, "quartz: unknown command %q\n", name)
+		flags.Usage()
+		return exitUsage
+	}
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil && !errors.Is(err, errUsage) {
+		fmt.Fprintf(stderr, "quartz %s: %v\n", name, err)
+	}
+
+	return exitCode(err)
+}
+
+// exitCode maps an error to the exit status of the process.
+func exitCode(err error) int {
+	var status *hn.StatusError
+	var netErr net.Error
+	var urlErr *url.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, hn.ErrItemNotFound), errors.Is(err, hn.ErrUserNotFound):
+		return exitNotFound
+	case errors.As(err, &status):
+		if status.StatusCode == http.StatusNotFound {
+			return exitNotFound
+		}
+		return exitNetwork
+	case errors.As(err, &netErr), errors.As(err, &urlErr), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	}
+
+	return exitError
+}
+
+// usageError reports a bad command line on stderr and returns errUsage.
+func (a *app) usageError(flags *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(a.stderr, "quartz %s: %s\n", flags.Name(), fmt.Sprintf(format, args...))
+	flags.Usage()
+
+	return errUsage
+}
+
+func (a *app) flagSet(name, synopsis string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: quartz %s %s\n", name, synopsis)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parse parses the flags of a command, expecting nargs positional
+// arguments.
+func (a *app) parse(flags *flag.FlagSet, args []string, nargs int) error {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if flags.NArg() != nargs {
+		return a.usageError(flags, "expected %d argument(s), got %d", nargs, flags.NArg())
+	}
+
+	return nil
+}
+
+func (a *app) encoder() (hn.Encoder, error) {
+	return hn.NewEncoder(a.format, a.columns...)
+}
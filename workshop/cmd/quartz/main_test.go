@@ -0,0 +1,260 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// api serves a tiny Hacker News: items 1 to 3, user pg and the top list.
+func api(t *testing.T) *httptest.Server {
+	responses := map[string]string{
+		"/maxitem.json":    "3",
+		"/item/1.json":     `{"id":1,"type":"story","by":"pg","title":"Y Combinator","score":57,"kids":[2],"descendants":1}`,
+		"/item/2.json":     `{"id":2,"type":"comment","by":"sama","parent":1,"text":"Nice."}`,
+		"/item/3.json":     `{"id":3,"type":"story","by":"sama","title":"Startups","score":12}`,
+		"/item/6.json":     `{"id":6,"type":"poll","by":"pg","title":"Poll: Arc?","score":3,"parts":[7,8]}`,
+		"/item/7.json":     `{"id":7,"type":"pollopt","by":"pg","poll":6,"text":"Yes","score":2}`,
+		"/item/404.json":   "null",
+		"/user/pg.json":    `{"id":"pg","created":1160418092,"karma":155111}`,
+		"/user/nope.json":  "null",
+		"/topstories.json": "[3,1,404]",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func quartz(t *testing.T, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-url", api(t).URL}, args...), strings.NewReader(""), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestQuartz(t *testing.T) {
+	t.Run("maxitem", func(t *testing.T) {
+		code, out, _ := quartz(t, "maxitem")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "3\n", out)
+	})
+
+	t.Run("item", func(t *testing.T) {
+		code, out, _ := quartz(t, "-format", "csv", "-columns", "id,title", "item", "1")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "id,title\r\n1,Y Combinator\r\n", out)
+	})
+
+	t.Run("poll with a failed option", func(t *testing.T) {
+		code, out, errOut := quartz(t, "-format", "json", "item", "6")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, out, `{"Id":7,"Text":"Yes","Score":2,"Percent":100}`)
+		assert.Contains(t, out, `{"Id":8,"Text":"","Score":0,"Percent":0,"Failed":true}`)
+		assert.Contains(t, errOut, "quartz: skipping option 8 of poll 6: ")
+	})
+
+	t.Run("item not found", func(t *testing.T) {
+		code, _, errOut := quartz(t, "item", "404")
+		assert.Equal(t, exitNotFound, code)
+		assert.Equal(t, "quartz item: item not found\n", errOut)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		code, _, _ := quartz(t, "item", "5")
+		assert.Equal(t, exitNetwork, code)
+	})
+
+	t.Run("unreachable API", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-url", "http://127.0.0.1:1", "maxitem"}, nil, &stdout, &stderr)
+		assert.Equal(t, exitNetwork, code)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		code, out, _ := quartz(t, "user", "pg")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "user: pg\ncreated: 2006-10-09T18:21:32Z\nkarma: 155111\nsubmitted: 0\n", out)
+
+		code, _, _ = quartz(t, "user", "nope")
+		assert.Equal(t, exitNotFound, code)
+	})
+
+	t.Run("story list", func(t *testing.T) {
+		code, out, errOut := quartz(t, "-concurrency", "2", "top", "-n", "3")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "Startups,12\nY Combinator,57\n", out)
+		assert.Equal(t, "quartz: skipping item 404: item not found\n", errOut)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		code, out, _ := quartz(t, "dump", "-n", "2", "-type", "story")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "Startups,12\nY Combinator,57\n", out)
+	})
+
+	t.Run("dump resumes from a checkpoint", func(t *testing.T) {
+		dir := t.TempDir()
+		output, checkpoint := filepath.Join(dir, "out.csv"), filepath.Join(dir, "dump.checkpoint")
+		dump := func(ids string) int {
+			var stdout, stderr bytes.Buffer
+			return run([]string{"-url", api(t).URL, "-format", "csv", "-columns", "id,title", "dump",
+				"-ids", "-", "-checkpoint", checkpoint, "-checkpoint-every", "1", "-o", output}, strings.NewReader(ids), &stdout, &stderr)
+		}
+
+		// The bad line stops the dump after two items.
+		assert.NotEqual(t, exitOK, dump("3\n1\nnot an id\n"))
+		blob, err := ioutil.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "id,title\r\n3,Startups\r\n1,Y Combinator\r\n", string(blob))
+
+		assert.Equal(t, exitOK, dump("3\n1\n2\n"))
+		blob, err = ioutil.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "id,title\r\n3,Startups\r\n1,Y Combinator\r\n2,\r\n", string(blob))
+		_, err = os.Stat(checkpoint)
+		assert.True(t, os.IsNotExist(err))
+
+		code, _, _ := quartz(t, "dump", "-checkpoint", checkpoint)
+		assert.Equal(t, exitUsage, code)
+	})
+
+	t.Run("feed", func(t *testing.T) {
+		code, out, _ := quartz(t, "-format", "atom", "top", "-n", "1")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, out, "<feed xmlns=\"http://www.w3.org/2005/Atom\">")
+		assert.Contains(t, out, "<title>Startups</title>")
+	})
+
+	t.Run("story", func(t *testing.T) {
+		code, out, _ := quartz(t, "story", "2")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "Y Combinator\n57 points by pg | 1 comment\n\n│ sama\n│ Nice.\n", out)
+	})
+
+	t.Run("epub", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "book.epub")
+		code, _, _ := quartz(t, "epub", "-o", path, "1", "3")
+		assert.Equal(t, exitOK, code)
+
+		z, err := zip.OpenReader(path)
+		assert.NoError(t, err)
+		defer z.Close()
+		var names []string
+		for _, f := range z.File {
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, []string{"mimetype", "META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml",
+			"OEBPS/style.css", "OEBPS/story-1.xhtml", "OEBPS/story-3.xhtml"}, names)
+
+		code, _, _ = quartz(t, "epub")
+		assert.Equal(t, exitUsage, code)
+	})
+
+	t.Run("browse without a terminal", func(t *testing.T) {
+		code, _, errOut := quartz(t, "browse")
+		assert.Equal(t, exitError, code)
+		assert.Equal(t, "quartz browse: browse needs a terminal\n", errOut)
+	})
+
+	t.Run("site", func(t *testing.T) {
+		store, dir := filepath.Join(t.TempDir(), "items"), filepath.Join(t.TempDir(), "site")
+		code, out, errOut := quartz(t, "site", "-fetch", "top", store, dir)
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "7 pages written, 0 unchanged, 0 removed\n", out)
+		assert.Equal(t, "quartz: skipping story 404: item not found\n", errOut)
+		assert.FileExists(t, filepath.Join(store, "2.json"))
+		assert.FileExists(t, filepath.Join(dir, "items", "1.html"))
+
+		code, out, _ = quartz(t, "site", store, dir)
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "0 pages written, 7 unchanged, 0 removed\n", out)
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		code, _, _ := quartz(t, "serve", "extra")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, _ = quartz(t, "serve", "-retries", "0")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, errOut := quartz(t, "serve", "-addr", "256.0.0.1:1")
+		assert.Equal(t, exitNetwork, code)
+		assert.Contains(t, errOut, "quartz serve: listen tcp")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		code, _, _ := quartz(t)
+		assert.Equal(t, exitUsage, code)
+
+		code, _, _ = quartz(t, "frobnicate")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, _ = quartz(t, "item")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, _ = quartz(t, "item", "abc")
+		assert.Equal(t, exitUsage, code)
+
+		code, _, _ = quartz(t, "-format", "yaml", "maxitem")
+		assert.Equal(t, exitUsage, code)
+	})
+}
+
+func TestRunServer(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- runServer(srv, l, stop, 10*time.Second)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	stop <- os.Interrupt
+
+	select {
+	case err := <-served:
+		t.Fatalf("stopped with a request in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.Equal(t, "done", <-body)
+	assert.NoError(t, <-served)
+}
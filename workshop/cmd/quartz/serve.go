@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+	"workshop-starter/pkg/server"
+)
+
+func (a *app) serve(args []string) error {
+	flags := a.flagSet("serve", "[flags]")
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	ttl := flags.Duration("ttl", time.Minute, "how long upstream answers are cached")
+	rate := flags.Float64("rate", 10, "upstream requests per second, 0 for no limit")
+	burst := flags.Int("burst", 10, "upstream requests let through at once")
+	retries := flags.Int("retries", 3, "attempts of failing upstream requests")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *retries < 1 || *rate < 0 || *burst < 1 {
+		return a.usageError(flags, "-retries and -burst must be at least 1 and -rate positive")
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	handler := server.New(a.client,
+		server.WithTTL(*ttl),
+		server.WithRateLimit(*rate, *burst),
+		server.WithRetries(*retries, 200*time.Millisecond),
+	)
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	// Stop on interrupt, letting the requests in flight finish.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+
+	fmt.Fprintf(a.stderr, "quartz: serving on http://%s\n", l.Addr())
+
+	return runServer(srv, l, stop, 10*time.Second)
+}
+
+// runServer serves on l until stop receives, then shuts srv down, giving the
+// requests in flight up to grace to finish.
+func runServer(srv *http.Server, l net.Listener, stop <-chan os.Signal, grace time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	if err := srv.Serve(l); err != http.ErrServerClosed {
+		return err
+	}
+
+	return <-done
+}
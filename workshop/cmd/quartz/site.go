@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/site"
+)
+
+func (a *app) site(args []string) error {
+	flags := a.flagSet("site", "[-fetch list] [-n count] <store> <dir>")
+	fetch := flags.String("fetch", "", "first mirror the stories of a list (top, new, best, ask, show or jobs) into the store")
+	n := flags.Int("n", 30, "number of stories to mirror with -fetch, 0 for the whole list")
+	if err := a.parse(flags, args, 2); err != nil {
+		return err
+	}
+
+	store, err := hn.NewFileStore(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *fetch != "" {
+		list := hn.StoryList(*fetch)
+		if *fetch == "jobs" {
+			list = hn.JobStories
+		}
+		if err := a.mirror(store, list, *n); err != nil {
+			return err
+		}
+	}
+
+	report, err := site.New(store, flags.Arg(1)).Generate()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(a.stdout, "%d pages written, %d unchanged, %d removed\n",
+		report.Written, report.Unchanged, report.Removed)
+
+	return err
+}
+
+// mirror stores the first n stories of a list with their comment trees.
+// Stories that cannot be fetched are reported on stderr and skipped.
+func (a *app) mirror(store *hn.FileStore, list hn.StoryList, n int) error {
+	ids, err := a.client.GetStories(list)
+	if err != nil {
+		return err
+	}
+	if n > 0 && n < len(ids) {
+		ids = ids[:n]
+	}
+
+	builder := hn.NewStoryBuilder(hn.StoringClient{Client: a.client, Store: store})
+	for _, id := range ids {
+		if _, err := builder.Build(id); err != nil {
+			fmt.Fprintf(a.stderr, "quartz: skipping story %d: %v\n", id, err)
+		}
+	}
+
+	return nil
+}
@@ -1,5 +1,7 @@
 module workshop-starter
 
+go 1.15
+
 require (
 	github.com/davecgh/go-spew v1.1.0
 	github.com/golang/mock v1.3.1
@@ -0,0 +1,141 @@
+package hn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// defaultCheckpointEvery is how many IDs are scanned between checkpoints
+// when WithCheckpoint is given no interval.
+const defaultCheckpointEvery = 100
+
+// Checkpoint is the progress of a Dump as persisted by WithCheckpoint.
+type Checkpoint struct {
+	// MaxItem is the ID the interrupted run started from. A resumed run keeps
+	// scanning below it, so items created in the meantime do not shift the
+	// window or get written twice.
+	MaxItem int
+	// Scanned is the number of IDs completed; the next one is MaxItem-Scanned.
+	Scanned int
+	LastID  int
+	Matched int
+	Written int
+	// Offset is the size of the output after the last written item.
+	Offset int64
+}
+
+// ResumableWriter is the output a checkpointed Dump resumes into, typically
+// an *os.File. Anything written after the last checkpoint is truncated away.
+type ResumableWriter interface {
+	io.Writer
+	io.Seeker
+	Truncate(size int64) error
+}
+
+// WithCheckpoint makes Dump save its progress to path every n scanned IDs,
+// and resume from that file when it exists. The file is removed once the
+// dump completes. Resuming needs a ResumableWriter, and WithTop cannot be
+// combined with checkpoints.
+func WithCheckpoint(path string, n int) DumpOption {
+	return func(d *Dump) {
+		d.checkpoint = path
+		d.checkpointEvery = n
+		if n <= 0 {
+			d.checkpointEvery = defaultCheckpointEvery
+		}
+	}
+}
+
+// LoadCheckpoint reads a checkpoint file. It reports false when there is
+// none.
+func LoadCheckpoint(path string) (Checkpoint, bool, error) {
+	var cp Checkpoint
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, false, nil
+	}
+	if err != nil {
+		return cp, false, err
+	}
+
+	return cp, true, json.Unmarshal(blob, &cp)
+}
+
+// save writes the checkpoint atomically, after making sure the output it
+// refers to reached the disk.
+func (cp Checkpoint) save(path string, w io.Writer) error {
+	if s, ok := w.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			return err
+		}
+	}
+
+	blob, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// resume rewinds w to the offset of the checkpoint. An output shorter than
+// that lost items the checkpoint counts as written, so it is refused rather
+// than padded.
+func (cp Checkpoint) resume(w io.Writer) error {
+	rw, ok := w.(ResumableWriter)
+	if !ok {
+		return errors.New("dump: resuming from a checkpoint needs a writer that can seek and truncate")
+	}
+	size, err := rw.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if size < cp.Offset {
+		return fmt.Errorf("dump: output has %d bytes, the checkpoint expects at least %d", size, cp.Offset)
+	}
+	if err := rw.Truncate(cp.Offset); err != nil {
+		return err
+	}
+	_, err = rw.Seek(cp.Offset, io.SeekStart)
+
+	return err
+}
+
+// countingWriter tracks the output offset for checkpoints.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+func (c *countingWriter) Sync() error {
+	if s, ok := c.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package hn_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumper_Checkpoint(t *testing.T) {
+	t.Run("resume after a crash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dir := t.TempDir()
+		checkpoint := filepath.Join(dir, "dump.checkpoint")
+		output, err := os.Create(filepath.Join(dir, "dump.csv"))
+		assert.NoError(t, err)
+		defer output.Close()
+
+		encoder := hn.NewCSVEncoder(hn.ColumnID, hn.ColumnTitle)
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+		client.EXPECT().GetItem(3).Return(hn.Item{}, fmt.Errorf("connection reset"))
+
+		dump := hn.NewDump(client, 4, hn.WithEncoder(encoder), hn.WithCheckpoint(checkpoint, 1), hn.WithFailFast())
+		_, err = dump.Run(output)
+		assert.Error(t, err)
+
+		cp, ok, err := hn.LoadCheckpoint(checkpoint)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, hn.Checkpoint{MaxItem: 5, Scanned: 2, LastID: 4, Matched: 2, Written: 2, Offset: 32}, cp)
+
+		// A line written after the last checkpoint must not survive.
+		_, err = output.WriteString("3,half a li")
+		assert.NoError(t, err)
+
+		// New items above the original max item are not looked at.
+		client.EXPECT().GetItem(3).Return(getItem(3), nil)
+		client.EXPECT().GetItem(2).Return(getItem(2), nil)
+
+		report, err := hn.NewDump(client, 4, hn.WithEncoder(encoder), hn.WithCheckpoint(checkpoint, 1)).Run(output)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, report.Written)
+		assert.Equal(t, 4, report.Scanned)
+
+		blob, err := ioutil.ReadFile(output.Name())
+		assert.NoError(t, err)
+		assert.Equal(t, "id,title\r\n0,Title 5\r\n0,Title 4\r\n0,Title 3\r\n0,Title 2\r\n", string(blob))
+
+		_, ok, err = hn.LoadCheckpoint(checkpoint)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("resume into a shortened output", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dir := t.TempDir()
+		checkpoint := filepath.Join(dir, "dump.checkpoint")
+		assert.NoError(t, ioutil.WriteFile(checkpoint, []byte(`{"MaxItem":5,"Scanned":2,"Written":2,"Offset":32}`), 0644))
+
+		for _, content := range []string{"", "id,title\r\n0,Title 5\r\n"} {
+			path := filepath.Join(dir, "dump.csv")
+			assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+			output, err := os.OpenFile(path, os.O_RDWR, 0644)
+			assert.NoError(t, err)
+
+			client := mock.NewMockClient(ctrl)
+			_, err = hn.NewDump(client, 4, hn.WithCheckpoint(checkpoint, 1)).Run(output)
+			output.Close()
+			assert.Error(t, err)
+
+			blob, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(blob))
+			_, ok, err := hn.LoadCheckpoint(checkpoint)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("resume needs a seekable writer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		checkpoint := filepath.Join(t.TempDir(), "dump.checkpoint")
+		assert.NoError(t, ioutil.WriteFile(checkpoint, []byte(`{"MaxItem":5,"Scanned":1}`), 0644))
+
+		client := mock.NewMockClient(ctrl)
+		_, err := hn.NewDump(client, 4, hn.WithCheckpoint(checkpoint, 1)).Run(&bytes.Buffer{})
+		assert.Error(t, err)
+	})
+
+	t.Run("no top-K", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		checkpoint := filepath.Join(t.TempDir(), "dump.checkpoint")
+		client := mock.NewMockClient(ctrl)
+		dump := hn.NewDump(client, 4, hn.WithCheckpoint(checkpoint, 1), hn.WithTop(2, hn.SortByScore))
+		_, err := dump.Run(&bytes.Buffer{})
+		assert.Error(t, err)
+	})
+}
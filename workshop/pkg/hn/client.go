@@ -1,13 +1,18 @@
 package hn
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 )
 
+// ErrItemNotFound is returned by GetItem when the API has no such item.
+var ErrItemNotFound = errors.New("item not found")
+
 type Client interface {
 	MaxItem() (int, error)
 	GetItem(itemID int) (Item, error)
@@ -15,62 +20,111 @@ type Client interface {
 
 type HackerNewsClient struct {
 	BaseUrl string
+	// HTTPClient makes the requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// StatusError is returned when the API answers with a status other than 200.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Non 200 Status Code: %d", e.StatusCode)
 }
 
 type Item struct {
-	Id     int
-	Author string `json:"by"`
-	Score  int
-	Url    string
-	Title  string
-	Text   string
-	Kids   []int
+	Id          int
+	Type        string
+	Author      string `json:"by"`
+	Score       int
+	Url         string
+	Title       string
+	Text        string
+	Time        int64
+	Kids        []int
+	Parent      int
+	Descendants int
+	Parts       []int
+	Poll        int
+	Deleted     bool
+	Dead        bool
 }
 
 func (s *HackerNewsClient) MaxItem() (int, error) {
-	response, err := http.Get(s.BaseUrl + "/maxitem.json")
+	response, err := s.get(context.Background(), "/maxitem.json")
 
 	if err != nil {
 		return 0, err
 	}
+	defer response.Body.Close()
 
 	parsedResponse, err := ioutil.ReadAll(response.Body)
-
-	if response.StatusCode != 200 {
-		return 0, fmt.Errorf("Non 200 Status Code")
+	if err != nil {
+		return 0, err
 	}
 
 	return strconv.Atoi(string(parsedResponse))
 }
 
 func (s *HackerNewsClient) GetItem(itemId int) (Item, error) {
-	targetUrl := s.BaseUrl + "/item/" + strconv.Itoa(itemId) + ".json"
-	response, err := http.Get(targetUrl)
+	return s.GetItemContext(context.Background(), itemId)
+}
+
+func (s *HackerNewsClient) GetItemContext(ctx context.Context, itemId int) (Item, error) {
+	var item Item
+	err := s.getJSON(ctx, "/item/"+strconv.Itoa(itemId)+".json", &item)
 
 	if err != nil {
 		return Item{}, err
 	}
-  defer response.Body.Close()
+
+	// The API answers with a literal null for IDs it does not know about.
+	if item.Id == 0 {
+		return Item{}, ErrItemNotFound
+	}
+
+	return item, nil
+}
+
+// get requests path below the base URL. The response is only returned for
+// a 200 status, and its body must then be closed.
+func (s *HackerNewsClient) get(ctx context.Context, path string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.BaseUrl+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
 
 	if response.StatusCode != 200 {
-		return Item{}, fmt.Errorf("Non 200 Status Code")
+		response.Body.Close()
+		return nil, &StatusError{StatusCode: response.StatusCode}
 	}
 
-	var item Item
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&item)
+	return response, nil
+}
 
+func (s *HackerNewsClient) getJSON(ctx context.Context, path string, v interface{}) error {
+	response, err := s.get(ctx, path)
 	if err != nil {
-		return Item{}, err
+		return err
 	}
+	defer response.Body.Close()
 
-	return item, nil
+	return json.NewDecoder(response.Body).Decode(v)
 }
 
 func NewHTTPClientFor(url string) *HackerNewsClient {
-	return &HackerNewsClient{url}
+	return &HackerNewsClient{BaseUrl: url}
 }
 
 func NewHTTPClient() *HackerNewsClient {
-	return &HackerNewsClient{"https://hacker-news.firebaseio.com/v0"}
+	return &HackerNewsClient{BaseUrl: "https://hacker-news.firebaseio.com/v0"}
 }
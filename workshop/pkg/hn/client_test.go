@@ -1,6 +1,7 @@
 package hn_test
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -98,6 +99,33 @@ func TestHTTPClient(t *testing.T) {
 			assert.Empty(t, item)
 		})
 
+		t.Run("Not found", func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+				_, _ = w.Write([]byte("null"))
+			}))
+			defer ts.Close()
+
+			item, err := hn.NewHTTPClientFor(ts.URL).GetItem(123)
+
+			assert.Equal(t, hn.ErrItemNotFound, err)
+			assert.Empty(t, item)
+		})
+
+		t.Run("Cancelled", func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			}))
+			defer ts.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			item, err := hn.NewHTTPClientFor(ts.URL).GetItemContext(ctx, 123)
+
+			assert.Error(t, err)
+			assert.Empty(t, item)
+		})
+
 		t.Run("Success", func(t *testing.T) {
 			t.Skip()
 			file, err := ioutil.ReadFile("testdata/item.json")
@@ -1,38 +1,399 @@
 package hn
 
 import (
-	"github.com/davecgh/go-spew/spew"
+	"errors"
+	"fmt"
 	"io"
-	"strconv"
+	"os"
+	"time"
 )
 
+// defaultMaxScan bounds how many IDs a filtered Dump looks at.
+const defaultMaxScan = 10000
+
 type Dump struct {
-	client Client
-	limit  int
+	client      Client
+	limit       int
+	encoder     Encoder
+	pollResults bool
+	filter      *Filter
+	maxScan     int
+	topK        int
+	sortKey     SortKey
+	now         func() time.Time
+	maxErrors   int
+	logger      Logger
+
+	checkpoint      string
+	checkpointEvery int
+
+	sinks       []Sink
+	strictSinks bool
+
+	// source is nil when dumping the IDs below MaxItem.
+	source IDSource
+}
+
+// Logger receives the diagnostics of a Dump. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(format string, v ...interface{}) {}
+
+// DumpReport tells what a Dump run did. After resuming from a checkpoint,
+// Written and Scanned include the interrupted runs.
+type DumpReport struct {
+	Written int
+	Scanned int
+	Skipped []FetchFailure
+	Elapsed time.Duration
+	// Sinks reports on the outputs added with WithSinks, in order.
+	Sinks []SinkReport
+}
+
+// DumpOption configures a Dump.
+type DumpOption func(*Dump)
+
+// WithEncoder sets the output format. The default is NewLegacyEncoder.
+func WithEncoder(encoder Encoder) DumpOption {
+	return func(d *Dump) {
+		d.encoder = encoder
+	}
+}
+
+// WithPollResults makes Dump fetch the options of every poll it writes so
+// that the encoder can print them.
+func WithPollResults() DumpOption {
+	return func(d *Dump) {
+		d.pollResults = true
+	}
+}
+
+// WithFilter makes Dump write only the items matching f. The limit then
+// counts matching items instead of scanned IDs.
+func WithFilter(f Filter) DumpOption {
+	return func(d *Dump) {
+		d.filter = &f
+	}
+}
+
+// WithMaxScan caps the number of IDs Dump looks at. When walking down from
+// MaxItem it defaults to the limit without a filter and to 10000 with one.
+// Other ID sources are not capped by default.
+func WithMaxScan(n int) DumpOption {
+	return func(d *Dump) {
+		d.maxScan = n
+	}
+}
+
+// WithTop makes Dump collect the matching items of the scanned window first
+// and then write only the k best of them according to key, best first. The
+// limit keeps counting the items of the window.
+func WithTop(k int, key SortKey) DumpOption {
+	return func(d *Dump) {
+		d.topK = k
+		d.sortKey = key
+	}
+}
+
+// WithFailFast makes Dump stop at the first item that cannot be fetched.
+func WithFailFast() DumpOption {
+	return WithMaxErrors(0)
+}
+
+// WithMaxErrors makes Dump stop once more than n items could not be
+// fetched. By default failing items are skipped without limit.
+func WithMaxErrors(n int) DumpOption {
+	return func(d *Dump) {
+		d.maxErrors = n
+	}
+}
+
+// WithLogger sets where Dump reports skipped items and other diagnostics.
+// By default they are discarded.
+func WithLogger(logger Logger) DumpOption {
+	return func(d *Dump) {
+		d.logger = logger
+	}
 }
 
-func NewDump(client Client, limit int) *Dump {
-	return &Dump{client: client, limit: limit}
+// WithClock sets the time source used for hotness ranking and timing.
+func WithClock(now func() time.Time) DumpOption {
+	return func(d *Dump) {
+		d.now = now
+	}
+}
+
+// WithLimit sets how many matching items a Dump writes at most. A negative
+// limit, the default of NewDumpFrom, means no limit.
+func WithLimit(limit int) DumpOption {
+	return func(d *Dump) {
+		d.limit = limit
+	}
+}
+
+// NewDump dumps up to limit items, walking down from the current MaxItem.
+func NewDump(client Client, limit int, opts ...DumpOption) *Dump {
+	return newDump(client, nil, limit, opts)
+}
+
+// NewDumpFrom dumps the items whose IDs come from source, such as IDRange,
+// IDList or IDReader.
+func NewDumpFrom(client Client, source IDSource, opts ...DumpOption) *Dump {
+	return newDump(client, source, -1, opts)
+}
+
+func newDump(client Client, source IDSource, limit int, opts []DumpOption) *Dump {
+	d := &Dump{
+		client:    client,
+		source:    source,
+		limit:     limit,
+		encoder:   NewLegacyEncoder(),
+		now:       time.Now,
+		maxErrors: -1,
+		logger:    discardLogger{},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 func (d *Dump) Dump(w io.Writer) error {
-	maxItem, err := d.client.MaxItem()
+	_, err := d.Run(w)
+	return err
+}
+
+// Run dumps like Dump and reports what was written and skipped.
+func (d *Dump) Run(w io.Writer) (DumpReport, error) {
+	start := d.now()
+	report, err := d.run(w)
+	report.Elapsed = d.now().Sub(start)
+
+	return report, err
+}
+
+func (d *Dump) run(w io.Writer) (DumpReport, error) {
+	var report DumpReport
+	if d.checkpoint != "" && d.topK > 0 {
+		return report, errors.New("dump: checkpoints cannot be combined with top-K selection")
+	}
+	if d.checkpoint != "" && len(d.sinks) > 0 {
+		return report, errors.New("dump: checkpoints cannot be combined with sinks")
+	}
+	for _, s := range d.sinks {
+		if s.W == nil {
+			return report, fmt.Errorf("dump: sink %s has no writer", s.Name)
+		}
+	}
+
+	cp, source, resumed, err := d.start(w)
 	if err != nil {
-		spew.Dump(err)
-		return err
+		return report, err
+	}
+	report.Scanned = cp.Scanned
+	out := &countingWriter{w: w, n: cp.Offset}
+
+	outs := d.outputs(out)
+	outs[0].written = cp.Written
+	for _, o := range outs {
+		if resumed && o.primary {
+			continue
+		}
+		if err := o.encoder.Header(o.w); err != nil {
+			if err := d.fail(outs, o, err); err != nil {
+				return d.finish(report, outs), err
+			}
+		}
 	}
 
-	for i := 0; i < d.limit; i++ {
-		itemID := maxItem - i
+	matched := cp.Matched
+	maxScan := d.scanCap()
+	for (d.limit < 0 || matched < d.limit) && (maxScan < 0 || report.Scanned < maxScan) {
+		itemID, ok, err := source.Next()
+		if err != nil {
+			return d.finish(report, outs), err
+		}
+		if !ok {
+			break
+		}
 		item, err := d.client.GetItem(itemID)
 		if err != nil {
+			d.logger.Printf("dump: skipping item %d: %v", itemID, err)
+			report.Skipped = append(report.Skipped, FetchFailure{Id: itemID, Err: err})
+			if d.maxErrors >= 0 && len(report.Skipped) > d.maxErrors {
+				report.Scanned++
+				return d.finish(report, outs), fmt.Errorf("dump: item %d: %w", itemID, err)
+			}
+		} else if d.filter == nil || d.filter.Match(item) {
+			matched++
+			if err := d.offer(outs, item); err != nil {
+				report.Scanned++
+				return d.finish(report, outs), err
+			}
+		}
+		report.Scanned++
+
+		if d.checkpoint != "" && report.Scanned%d.checkpointEvery == 0 {
+			cp.Scanned, cp.LastID, cp.Matched, cp.Written, cp.Offset = report.Scanned, itemID, matched, outs[0].written, out.n
+			if err := cp.save(d.checkpoint, out); err != nil {
+				return d.finish(report, outs), err
+			}
+		}
+	}
+
+	for _, o := range outs {
+		if o.top != nil {
+			for _, item := range o.top.sorted() {
+				if o.err != nil {
+					break
+				}
+				if err := d.encode(outs, o, d.entry(item)); err != nil {
+					return d.finish(report, outs), err
+				}
+			}
+		}
+		if o.err == nil {
+			if err := o.encoder.Footer(o.w, o.written); err != nil {
+				if err := d.fail(outs, o, err); err != nil {
+					return d.finish(report, outs), err
+				}
+			}
+		}
+	}
+	report = d.finish(report, outs)
+	for _, o := range outs {
+		if o.err != nil {
+			return report, o.wrap()
+		}
+	}
+
+	if d.checkpoint != "" {
+		if err := os.Remove(d.checkpoint); err != nil && !os.IsNotExist(err) {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// offer hands a matching item to every output still running, either to its
+// top-K selection or straight to its encoder.
+func (d *Dump) offer(outs []*output, item Item) error {
+	var e *Entry
+	for _, o := range outs {
+		if o.err != nil || o.filter != nil && !o.filter.Match(item) {
 			continue
 		}
-		_, err = io.WriteString(w, item.Title+","+strconv.Itoa(item.Score)+"\n")
-		if err != nil {
+		if o.top != nil {
+			o.top.offer(item)
+			continue
+		}
+		if e == nil {
+			entry := d.entry(item)
+			e = &entry
+		}
+		if err := d.encode(outs, o, *e); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+func (d *Dump) encode(outs []*output, o *output, e Entry) error {
+	if err := o.encoder.Encode(o.w, e, o.written); err != nil {
+		return d.fail(outs, o, err)
+	}
+	o.written++
+
+	return nil
+}
+
+// finish copies the counts of the outputs into report.
+func (d *Dump) finish(report DumpReport, outs []*output) DumpReport {
+	report.Written = outs[0].written
+	report.Sinks = nil
+	for _, o := range outs[1:] {
+		report.Sinks = append(report.Sinks, SinkReport{Name: o.name, Written: o.written, Err: o.err})
+	}
+
+	return report
+}
+
+// start returns where the scan begins: the saved checkpoint when there is
+// one to resume from, or the beginning otherwise. The returned source is
+// positioned after the IDs the checkpoint already covers.
+func (d *Dump) start(w io.Writer) (Checkpoint, IDSource, bool, error) {
+	var cp Checkpoint
+	resumed := false
+	if d.checkpoint != "" {
+		var err error
+		cp, resumed, err = LoadCheckpoint(d.checkpoint)
+		if err != nil {
+			return cp, nil, false, err
+		}
+	}
+	if resumed {
+		d.logger.Printf("dump: resuming after %d items, last was %d", cp.Scanned, cp.LastID)
+		if err := cp.resume(w); err != nil {
+			return cp, nil, true, err
+		}
+	}
+
+	if d.source != nil {
+		for i := 0; i < cp.Scanned; i++ {
+			if _, _, err := d.source.Next(); err != nil {
+				return cp, nil, resumed, err
+			}
+		}
+		return cp, d.source, resumed, nil
+	}
+
+	if !resumed {
+		maxItem, err := d.client.MaxItem()
+		if err != nil {
+			d.logger.Printf("dump: max item: %v", err)
+			return cp, nil, false, err
+		}
+		cp.MaxItem = maxItem
+	}
+
+	if next := cp.MaxItem - cp.Scanned; next > 0 {
+		return cp, IDRange(next, 1), resumed, nil
+	}
+
+	return cp, IDList(), resumed, nil
+}
+
+// scanCap returns how many IDs may be scanned, or -1 for no cap.
+func (d *Dump) scanCap() int {
+	switch {
+	case d.maxScan > 0:
+		return d.maxScan
+	case d.source != nil:
+		return -1
+	case d.filter == nil:
+		return d.limit
+	}
+
+	return defaultMaxScan
+}
+
+func (d *Dump) entry(item Item) Entry {
+	e := Entry{Item: item}
+	if d.pollResults && item.IsPoll() {
+		// Options that fail keep only their ID; the others are still shown.
+		poll, failures := fetchPoll(d.client.GetItem, item.Parts)
+		for _, f := range failures {
+			d.logger.Printf("dump: skipping option %d of poll %d: %v", f.Id, item.Id, f.Err)
+		}
+		e.Poll = &poll
+	}
+
+	return e
+}
@@ -2,10 +2,13 @@ package hn_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"log"
 	"testing"
+	"time"
 	"workshop-starter/pkg/hn"
 	"workshop-starter/pkg/hn/mock"
 )
@@ -75,6 +78,147 @@ func TestDumper(t *testing.T) {
 	})
 }
 
+func TestDumper_Report(t *testing.T) {
+	failed := fmt.Errorf("Failed")
+
+	t.Run("report", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(hn.Item{}, failed)
+		client.EXPECT().GetItem(3).Return(getItem(3), nil)
+
+		clock := time.Unix(0, 0)
+		tick := func() time.Time {
+			clock = clock.Add(time.Second)
+			return clock
+		}
+
+		var logs bytes.Buffer
+		var b bytes.Buffer
+		dump := hn.NewDump(client, 3, hn.WithLogger(log.New(&logs, "", 0)), hn.WithClock(tick))
+		report, err := dump.Run(&b)
+
+		assert.NoError(t, err)
+		assert.Equal(t, hn.DumpReport{
+			Written: 2,
+			Scanned: 3,
+			Skipped: []hn.FetchFailure{{Id: 4, Err: failed}},
+			Elapsed: time.Second,
+		}, report)
+		assert.Equal(t, "dump: skipping item 4: Failed\n", logs.String())
+	})
+
+	t.Run("max item error is logged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(0, failed)
+
+		var logs bytes.Buffer
+		_, err := hn.NewDump(client, 3, hn.WithLogger(log.New(&logs, "", 0))).Run(&bytes.Buffer{})
+
+		assert.Equal(t, failed, err)
+		assert.Equal(t, "dump: max item: Failed\n", logs.String())
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(hn.Item{}, failed)
+
+		var b bytes.Buffer
+		report, err := hn.NewDump(client, 3, hn.WithFailFast()).Run(&b)
+
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, failed))
+		assert.Equal(t, 1, report.Written)
+		assert.Equal(t, "Title 5,5\n", b.String())
+	})
+
+	t.Run("error budget", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(hn.Item{}, failed)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+		client.EXPECT().GetItem(3).Return(hn.Item{}, failed)
+
+		report, err := hn.NewDump(client, 5, hn.WithMaxErrors(1)).Run(&bytes.Buffer{})
+
+		assert.Error(t, err)
+		assert.Len(t, report.Skipped, 2)
+		assert.Equal(t, 3, report.Scanned)
+	})
+}
+
+func TestDumper_Polls(t *testing.T) {
+	t.Run("poll results", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(126809, nil)
+		client.EXPECT().GetItem(126809).Return(getItemFromTestData(t, "poll"), nil)
+		client.EXPECT().GetItem(126810).Return(getItemFromTestData(t, "pollopt_1"), nil)
+		client.EXPECT().GetItem(126811).Return(getItemFromTestData(t, "pollopt_2"), nil)
+		client.EXPECT().GetItem(126812).Return(getItemFromTestData(t, "pollopt_3"), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDump(client, 1, hn.WithPollResults()).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Poll: What would happen if News.YC had explicit support for polls?,46\n"+
+			"  It would be a good thing.,335,67.0%\n"+
+			"  It would be a bad thing.,117,23.4%\n"+
+			"  It would make no difference.,48,9.6%\n", b.String())
+	})
+
+	t.Run("failed options", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(126809, nil)
+		client.EXPECT().GetItem(126809).Return(getItemFromTestData(t, "poll"), nil)
+		client.EXPECT().GetItem(126810).Return(getItemFromTestData(t, "pollopt_1"), nil)
+		client.EXPECT().GetItem(126811).Return(hn.Item{}, fmt.Errorf("connection reset"))
+		client.EXPECT().GetItem(126812).Return(getItemFromTestData(t, "pollopt_3"), nil)
+
+		var b, logs bytes.Buffer
+		err := hn.NewDump(client, 1, hn.WithPollResults(), hn.WithLogger(log.New(&logs, "", 0))).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Poll: What would happen if News.YC had explicit support for polls?,46\n"+
+			"  It would be a good thing.,335,87.5%\n"+
+			"  <failed 126811>\n"+
+			"  It would make no difference.,48,12.5%\n", b.String())
+		assert.Equal(t, "dump: skipping option 126811 of poll 126809: connection reset\n", logs.String())
+	})
+
+	t.Run("polls are not expanded by default", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(126809, nil)
+		client.EXPECT().GetItem(126809).Return(getItemFromTestData(t, "poll"), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDump(client, 1).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Poll: What would happen if News.YC had explicit support for polls?,46\n", b.String())
+	})
+}
+
 func getItem(itemID int) hn.Item {
 	items := map[int]hn.Item{
 		5: hn.Item{Title: "Title 5", Score: 5},
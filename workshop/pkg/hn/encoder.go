@@ -0,0 +1,406 @@
+package hn
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is what Dump hands to an Encoder: the item and, for polls when
+// WithPollResults is set, its options.
+type Entry struct {
+	Item
+	Poll *Poll
+}
+
+// Encoder writes dumped entries in some output format. Encoders keep no state
+// between calls; n is the number of entries written before the current call.
+type Encoder interface {
+	Header(w io.Writer) error
+	Encode(w io.Writer, e Entry, n int) error
+	Footer(w io.Writer, n int) error
+}
+
+// Column selects an Item field for the structured encoders.
+type Column string
+
+const (
+	ColumnID          Column = "id"
+	ColumnType        Column = "type"
+	ColumnTitle       Column = "title"
+	ColumnURL         Column = "url"
+	ColumnAuthor      Column = "by"
+	ColumnScore       Column = "score"
+	ColumnDescendants Column = "descendants"
+	ColumnTime        Column = "time"
+	ColumnText        Column = "text"
+)
+
+// DefaultColumns are used when an encoder is created without columns.
+var DefaultColumns = []Column{ColumnID, ColumnTitle, ColumnScore, ColumnURL, ColumnAuthor}
+
+// ParseColumns parses a comma separated list of column names.
+func ParseColumns(s string) ([]Column, error) {
+	var columns []Column
+	for _, name := range strings.Split(s, ",") {
+		c := Column(strings.TrimSpace(name))
+		if _, ok := c.value(Item{}); !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, nil
+}
+
+// value returns the column of item as a string or a number.
+func (c Column) value(item Item) (interface{}, bool) {
+	switch c {
+	case ColumnID:
+		return item.Id, true
+	case ColumnType:
+		return item.Type, true
+	case ColumnTitle:
+		return item.Title, true
+	case ColumnURL:
+		return item.Url, true
+	case ColumnAuthor:
+		return item.Author, true
+	case ColumnScore:
+		return item.Score, true
+	case ColumnDescendants:
+		return item.Descendants, true
+	case ColumnTime:
+		return item.Time, true
+	case ColumnText:
+		return item.Text, true
+	}
+
+	return nil, false
+}
+
+func (c Column) text(item Item) string {
+	v, _ := c.value(item)
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return v
+	}
+
+	return ""
+}
+
+func columnsOrDefault(columns []Column) []Column {
+	if len(columns) == 0 {
+		return DefaultColumns
+	}
+
+	return columns
+}
+
+func hasColumn(columns []Column, c Column) bool {
+	for _, column := range columns {
+		if column == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewEncoder returns the encoder for a format name: legacy, csv, tsv, json,
+// jsonl, markdown, rss or atom. The legacy format ignores columns; the feed
+// formats include the full text of items when the columns list text.
+func NewEncoder(format string, columns ...Column) (Encoder, error) {
+	switch format {
+	case "legacy", "":
+		return NewLegacyEncoder(), nil
+	case "csv":
+		return NewCSVEncoder(columns...), nil
+	case "tsv":
+		return NewTSVEncoder(columns...), nil
+	case "json":
+		return NewJSONEncoder(columns...), nil
+	case "jsonl":
+		return NewJSONLinesEncoder(columns...), nil
+	case "markdown", "md":
+		return NewMarkdownEncoder(columns...), nil
+	case "rss":
+		return NewRSSEncoder(FeedOptions{FullText: hasColumn(columns, ColumnText)}), nil
+	case "atom":
+		return NewAtomEncoder(FeedOptions{FullText: hasColumn(columns, ColumnText)}), nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type legacyEncoder struct{}
+
+// NewLegacyEncoder writes "title,score" lines, followed by indented option
+// lines for polls. Titles are not quoted.
+func NewLegacyEncoder() Encoder {
+	return legacyEncoder{}
+}
+
+func (legacyEncoder) Header(w io.Writer) error {
+	return nil
+}
+
+func (legacyEncoder) Encode(w io.Writer, e Entry, n int) error {
+	_, err := io.WriteString(w, e.Title+","+strconv.Itoa(e.Score)+"\n")
+	if err != nil || e.Poll == nil {
+		return err
+	}
+
+	for _, o := range e.Poll.Options {
+		line := fmt.Sprintf("  %s,%d,%.1f%%\n", InlineText(o.Text), o.Score, o.Percent)
+		if o.Failed {
+			line = fmt.Sprintf("  <failed %d>\n", o.Id)
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (legacyEncoder) Footer(w io.Writer, n int) error {
+	return nil
+}
+
+type csvEncoder struct {
+	columns []Column
+}
+
+// NewCSVEncoder writes RFC 4180 CSV with a header row.
+func NewCSVEncoder(columns ...Column) Encoder {
+	return csvEncoder{columnsOrDefault(columns)}
+}
+
+func (c csvEncoder) write(w io.Writer, record []string) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func (c csvEncoder) Header(w io.Writer) error {
+	record := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		record[i] = string(col)
+	}
+
+	return c.write(w, record)
+}
+
+func (c csvEncoder) Encode(w io.Writer, e Entry, n int) error {
+	record := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		record[i] = col.text(e.Item)
+	}
+
+	return c.write(w, record)
+}
+
+func (c csvEncoder) Footer(w io.Writer, n int) error {
+	return nil
+}
+
+type tsvEncoder struct {
+	columns []Column
+}
+
+// NewTSVEncoder writes tab separated values with a header row. Tabs and line
+// breaks inside values are replaced by spaces.
+func NewTSVEncoder(columns ...Column) Encoder {
+	return tsvEncoder{columnsOrDefault(columns)}
+}
+
+var tsvEscaper = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+func (t tsvEncoder) Header(w io.Writer) error {
+	names := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		names[i] = string(col)
+	}
+	_, err := io.WriteString(w, strings.Join(names, "\t")+"\n")
+
+	return err
+}
+
+func (t tsvEncoder) Encode(w io.Writer, e Entry, n int) error {
+	values := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		values[i] = tsvEscaper.Replace(col.text(e.Item))
+	}
+	_, err := io.WriteString(w, strings.Join(values, "\t")+"\n")
+
+	return err
+}
+
+func (t tsvEncoder) Footer(w io.Writer, n int) error {
+	return nil
+}
+
+// jsonObject encodes the selected columns of e, in order, plus the poll
+// options when present.
+func jsonObject(columns []Column, e Entry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		v, _ := col.value(e.Item)
+		if err := writeJSONField(&b, string(col), v); err != nil {
+			return nil, err
+		}
+	}
+	if e.Poll != nil {
+		if len(columns) > 0 {
+			b.WriteByte(',')
+		}
+		if err := writeJSONField(&b, "poll", e.Poll); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+func writeJSONField(b *bytes.Buffer, key string, value interface{}) error {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.Write(k)
+	b.WriteByte(':')
+	b.Write(v)
+
+	return nil
+}
+
+type jsonEncoder struct {
+	columns []Column
+}
+
+// NewJSONEncoder writes a JSON array of objects holding the selected columns.
+func NewJSONEncoder(columns ...Column) Encoder {
+	return jsonEncoder{columnsOrDefault(columns)}
+}
+
+func (j jsonEncoder) Header(w io.Writer) error {
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+func (j jsonEncoder) Encode(w io.Writer, e Entry, n int) error {
+	object, err := jsonObject(j.columns, e)
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if n == 0 {
+		separator = "\n  "
+	}
+	_, err = io.WriteString(w, separator+string(object))
+
+	return err
+}
+
+func (j jsonEncoder) Footer(w io.Writer, n int) error {
+	closing := "]\n"
+	if n > 0 {
+		closing = "\n]\n"
+	}
+	_, err := io.WriteString(w, closing)
+
+	return err
+}
+
+type jsonLinesEncoder struct {
+	columns []Column
+}
+
+// NewJSONLinesEncoder writes one JSON object per line.
+func NewJSONLinesEncoder(columns ...Column) Encoder {
+	return jsonLinesEncoder{columnsOrDefault(columns)}
+}
+
+func (j jsonLinesEncoder) Header(w io.Writer) error {
+	return nil
+}
+
+func (j jsonLinesEncoder) Encode(w io.Writer, e Entry, n int) error {
+	object, err := jsonObject(j.columns, e)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(object, '\n'))
+
+	return err
+}
+
+func (j jsonLinesEncoder) Footer(w io.Writer, n int) error {
+	return nil
+}
+
+type markdownEncoder struct {
+	columns []Column
+}
+
+// NewMarkdownEncoder writes a Markdown table.
+func NewMarkdownEncoder(columns ...Column) Encoder {
+	return markdownEncoder{columnsOrDefault(columns)}
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+func (m markdownEncoder) row(w io.Writer, cells []string) error {
+	_, err := io.WriteString(w, "| "+strings.Join(cells, " | ")+" |\n")
+	return err
+}
+
+func (m markdownEncoder) Header(w io.Writer) error {
+	names := make([]string, len(m.columns))
+	rule := make([]string, len(m.columns))
+	for i, col := range m.columns {
+		names[i] = string(col)
+		rule[i] = "---"
+	}
+	if err := m.row(w, names); err != nil {
+		return err
+	}
+
+	return m.row(w, rule)
+}
+
+func (m markdownEncoder) Encode(w io.Writer, e Entry, n int) error {
+	cells := make([]string, len(m.columns))
+	for i, col := range m.columns {
+		cells[i] = markdownEscaper.Replace(col.text(e.Item))
+	}
+
+	return m.row(w, cells)
+}
+
+func (m markdownEncoder) Footer(w io.Writer, n int) error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package hn_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeEntries(t *testing.T, enc hn.Encoder, entries ...hn.Entry) string {
+	t.Helper()
+	var b bytes.Buffer
+	assert.NoError(t, enc.Header(&b))
+	for i, e := range entries {
+		assert.NoError(t, enc.Encode(&b, e, i))
+	}
+	assert.NoError(t, enc.Footer(&b, len(entries)))
+
+	return b.String()
+}
+
+func encoderEntries() []hn.Entry {
+	return []hn.Entry{
+		{Item: hn.Item{Id: 1, Title: "Hello, \"world\"", Score: 10, Url: "http://example.com", Author: "pg"}},
+		{Item: hn.Item{Id: 2, Title: "Tabs\tand | pipes", Score: 3, Author: "dhouston"}},
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	columns := []hn.Column{hn.ColumnID, hn.ColumnTitle, hn.ColumnScore}
+
+	t.Run("legacy", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewLegacyEncoder(), encoderEntries()...)
+		assert.Equal(t, "Hello, \"world\",10\nTabs\tand | pipes,3\n", out)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewCSVEncoder(columns...), encoderEntries()...)
+		assert.Equal(t, "id,title,score\r\n1,\"Hello, \"\"world\"\"\",10\r\n2,Tabs\tand | pipes,3\r\n", out)
+
+		records, err := csv.NewReader(bytes.NewBufferString(out)).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello, \"world\"", records[1][1])
+	})
+
+	t.Run("csv default columns", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewCSVEncoder())
+		assert.Equal(t, "id,title,score,url,by\r\n", out)
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewTSVEncoder(columns...), encoderEntries()...)
+		assert.Equal(t, "id\ttitle\tscore\n1\tHello, \"world\"\t10\n2\tTabs and | pipes\t3\n", out)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewJSONEncoder(columns...), encoderEntries()...)
+		assert.Equal(t, "[\n  {\"id\":1,\"title\":\"Hello, \\\"world\\\"\",\"score\":10},\n  {\"id\":2,\"title\":\"Tabs\\tand | pipes\",\"score\":3}\n]\n", out)
+
+		var decoded []map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(out), &decoded))
+		assert.Len(t, decoded, 2)
+	})
+
+	t.Run("empty json", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewJSONEncoder(columns...))
+		assert.Equal(t, "[]\n", out)
+	})
+
+	t.Run("json lines with poll", func(t *testing.T) {
+		poll := hn.Entry{
+			Item: hn.Item{Id: 3, Title: "Poll"},
+			Poll: &hn.Poll{Votes: 1, Options: []hn.PollOption{{Id: 4, Text: "Yes", Score: 1, Percent: 100}}},
+		}
+		out := encodeEntries(t, hn.NewJSONLinesEncoder(hn.ColumnID), encoderEntries()[0], poll)
+		assert.Equal(t, "{\"id\":1}\n{\"id\":3,\"poll\":{\"Options\":[{\"Id\":4,\"Text\":\"Yes\",\"Score\":1,\"Percent\":100}],\"Votes\":1}}\n", out)
+	})
+
+	t.Run("legacy with poll", func(t *testing.T) {
+		poll := hn.Entry{
+			Item: hn.Item{Id: 3, Title: "Poll", Score: 2},
+			Poll: &hn.Poll{Votes: 1, Options: []hn.PollOption{
+				{Id: 4, Text: "Don&#x27;t <i>know</i>", Score: 1, Percent: 100},
+				{Id: 5, Failed: true},
+			}},
+		}
+		out := encodeEntries(t, hn.NewLegacyEncoder(), poll)
+		assert.Equal(t, "Poll,2\n  Don't know,1,100.0%\n  <failed 5>\n", out)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		out := encodeEntries(t, hn.NewMarkdownEncoder(columns...), encoderEntries()...)
+		assert.Equal(t, "| id | title | score |\n| --- | --- | --- |\n| 1 | Hello, \"world\" | 10 |\n| 2 | Tabs\tand \\| pipes | 3 |\n", out)
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		for _, format := range []string{"legacy", "csv", "tsv", "json", "jsonl", "markdown", "rss", "atom"} {
+			enc, err := hn.NewEncoder(format)
+			assert.NoError(t, err)
+			assert.NotNil(t, enc)
+		}
+
+		_, err := hn.NewEncoder("xml")
+		assert.Error(t, err)
+	})
+
+	t.Run("parse columns", func(t *testing.T) {
+		columns, err := hn.ParseColumns("id, by,time")
+		assert.NoError(t, err)
+		assert.Equal(t, []hn.Column{hn.ColumnID, hn.ColumnAuthor, hn.ColumnTime}, columns)
+
+		_, err = hn.ParseColumns("id,karma")
+		assert.Error(t, err)
+	})
+}
+
+func TestDumper_Encoder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mock.NewMockClient(ctrl)
+	client.EXPECT().MaxItem().Return(5, nil)
+	client.EXPECT().GetItem(5).Return(hn.Item{Id: 5, Title: "One, two", Score: 5}, nil)
+	client.EXPECT().GetItem(4).Return(hn.Item{Id: 4, Title: "Three", Score: 4}, nil)
+
+	var b bytes.Buffer
+	err := hn.NewDump(client, 2, hn.WithEncoder(hn.NewCSVEncoder(hn.ColumnID, hn.ColumnTitle))).Dump(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,title\r\n5,\"One, two\"\r\n4,Three\r\n", b.String())
+}
@@ -0,0 +1,297 @@
+package hn
+
+import (
+	"archive/zip"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EPUBWriter packages stories into an EPUB 3 book, one chapter per story
+// with its comment tree, for reading discussions on e-readers. The book
+// only depends on the stories and the fields, so the same input gives the
+// same file.
+type EPUBWriter struct {
+	// Title of the book; the title of the story, or a generic one when
+	// there are several, when empty.
+	Title string
+	// Language of the book, "en" when empty.
+	Language string
+	// Modified is recorded as the modification date of the book. When zero
+	// the time of the newest story or comment is used.
+	Modified time.Time
+}
+
+// epubChapter is what a chapter template is executed with.
+type epubChapter struct {
+	htmlStory
+	File     string
+	Language string
+}
+
+type epubBook struct {
+	Id       string
+	Title    string
+	Language string
+	Modified string
+	Authors  []string
+	Chapters []epubChapter
+	modified time.Time
+}
+
+// epubFile is a file of the archive and the template that renders it.
+type epubFile struct {
+	name     string
+	template string
+	data     interface{}
+}
+
+// Write writes the book to w. A story given more than once gets a single
+// chapter, where it first appears.
+func (e EPUBWriter) Write(w io.Writer, stories ...Story) error {
+	stories = uniqueStories(stories)
+	if len(stories) == 0 {
+		return fmt.Errorf("epub: no stories")
+	}
+	book := e.book(stories)
+	files := []epubFile{
+		{"META-INF/container.xml", "container", book},
+		{"OEBPS/content.opf", "opf", book},
+		{"OEBPS/nav.xhtml", "nav", book},
+		{"OEBPS/style.css", "css", book},
+	}
+	for _, c := range book.Chapters {
+		files = append(files, epubFile{"OEBPS/" + c.File, "chapter", c})
+	}
+
+	z := zip.NewWriter(w)
+	// The mimetype comes first and uncompressed so that the format can be
+	// recognized from the first bytes of the file.
+	err := writeZipEntry(z, "mimetype", zip.Store, book.modified, func(w io.Writer) error {
+		_, err := io.WriteString(w, "application/epub+zip")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		f := f
+		err := writeZipEntry(z, f.name, zip.Deflate, book.modified, func(w io.Writer) error {
+			return epubTemplates.ExecuteTemplate(w, f.template, f.data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func (e EPUBWriter) book(stories []Story) epubBook {
+	book := epubBook{Title: e.Title, Language: e.Language}
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	if book.Title == "" {
+		book.Title = stories[0].Title
+		if len(stories) > 1 {
+			book.Title = fmt.Sprintf("Hacker News: %d stories", len(stories))
+		}
+	}
+
+	var ids []string
+	var newest int64
+	seen := map[string]bool{}
+	for _, s := range stories {
+		ids = append(ids, strconv.Itoa(s.Id))
+		if s.Author != "" && !seen[s.Author] {
+			seen[s.Author] = true
+			book.Authors = append(book.Authors, s.Author)
+		}
+		if t := newestTime(s.Time, s.Comments); t > newest {
+			newest = t
+		}
+		book.Chapters = append(book.Chapters, epubChapter{
+			htmlStory: htmlStory{
+				Story:    s,
+				Posted:   newHTMLTime(s.Time),
+				Body:     storyBody(s),
+				Comments: HTMLRenderer{}.comments(s.Comments, 1),
+				More:     len(s.More),
+			},
+			File:     fmt.Sprintf("story-%d.xhtml", s.Id),
+			Language: book.Language,
+		})
+	}
+	book.Id = "urn:hn:" + strings.Join(ids, ",")
+
+	book.modified = e.Modified.UTC()
+	if e.Modified.IsZero() {
+		book.modified = time.Unix(newest, 0).UTC()
+	}
+	book.Modified = book.modified.Format(time.RFC3339)
+
+	return book
+}
+
+func uniqueStories(stories []Story) []Story {
+	seen := make(map[int]bool, len(stories))
+	unique := make([]Story, 0, len(stories))
+	for _, s := range stories {
+		if !seen[s.Id] {
+			seen[s.Id] = true
+			unique = append(unique, s)
+		}
+	}
+
+	return unique
+}
+
+func newestTime(t int64, comments []Comment) int64 {
+	for _, c := range comments {
+		if n := newestTime(c.Time, c.ChildComments); n > t {
+			t = n
+		}
+	}
+
+	return t
+}
+
+// writeZipEntry adds a file to the archive. The time is only recorded in
+// the MS-DOS fields: setting FileHeader.Modified adds an extra field, which
+// the mimetype entry must not have.
+func writeZipEntry(z *zip.Writer, name string, method uint16, modified time.Time, write func(w io.Writer) error) error {
+	header := &zip.FileHeader{Name: name, Method: method}
+	if modified.Year() >= 1980 {
+		header.ModifiedDate = uint16(modified.Day() + int(modified.Month())<<5 + (modified.Year()-1980)<<9)
+		header.ModifiedTime = uint16(modified.Second()/2 + modified.Minute()<<5 + modified.Hour()<<11)
+	} else {
+		header.ModifiedDate = 1<<5 + 1
+	}
+	w, err := z.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	return write(w)
+}
+
+var epubTemplates = template.Must(template.New("epub").Funcs(htmlFuncs).Funcs(template.FuncMap{
+	"xmlDecl": func() template.HTML { return `<?xml version="1.0" encoding="UTF-8"?>` },
+}).Parse(`
+{{- define "container"}}{{xmlDecl}}
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+{{end}}
+
+{{- define "opf"}}{{xmlDecl}}
+<package version="3.0" xmlns="http://www.idpf.org/2007/opf" unique-identifier="book-id" xml:lang="{{.Language}}">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">{{.Id}}</dc:identifier>
+<dc:title>{{.Title}}</dc:title>
+<dc:language>{{.Language}}</dc:language>
+{{- range .Authors}}
+<dc:creator>{{.}}</dc:creator>
+{{- end}}
+<dc:publisher>Hacker News</dc:publisher>
+<meta property="dcterms:modified">{{.Modified}}</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="css" href="style.css" media-type="text/css"/>
+{{- range .Chapters}}
+<item id="story-{{.Id}}" href="{{.File}}" media-type="application/xhtml+xml"/>
+{{- end}}
+</manifest>
+<spine>
+{{- range .Chapters}}
+<itemref idref="story-{{.Id}}"/>
+{{- end}}
+</spine>
+</package>
+{{end}}
+
+{{- define "nav"}}{{xmlDecl}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Language}}" lang="{{.Language}}">
+<head>
+<meta charset="utf-8"/>
+<title>{{.Title}}</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>{{.Title}}</h1>
+<ol>
+{{- range .Chapters}}
+<li><a href="{{.File}}">{{.Title}}</a></li>
+{{- end}}
+</ol>
+</nav>
+</body>
+</html>
+{{end}}
+
+{{- define "css"}}body { font-family: serif; line-height: 1.4; }
+h1 { font-size: 1.4em; }
+.meta, .byline { color: #666; font-size: .85em; }
+.comment { margin: .8em 0 0 0; padding-left: .8em; border-left: 1px solid #aaa; }
+.comment .comment { margin-left: .4em; }
+.byline { margin: 0; }
+.text p { margin: .3em 0; }
+pre { white-space: pre-wrap; font-size: .85em; }
+blockquote { margin: .3em 0 .3em .6em; font-style: italic; }
+.deleted, .dead, .failed { color: #888; }
+.more { font-size: .85em; font-style: italic; }
+{{end}}
+
+{{- define "chapter"}}{{xmlDecl}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{.Language}}" lang="{{.Language}}">
+<head>
+<meta charset="utf-8"/>
+<title>{{.Title}}</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+<section id="item-{{.Id}}">
+<h1>{{.Title}}</h1>
+<p class="meta">{{.Score}} points by {{.Author}}{{if .Posted.ISO}} on <time datetime="{{.Posted.ISO}}">{{.Posted.Text}}</time>{{end}} | {{plural .Descendants "comment" "comments"}}</p>
+{{- if .Url}}
+<p class="meta"><a href="{{.Url}}">{{.Url}}</a></p>
+{{- end}}
+{{- with .Body}}
+<div class="text">{{.}}</div>
+{{- end}}
+{{- with .Poll}}
+<ol class="poll">
+{{- range .Options}}
+<li>{{if .Failed}}&lt;failed {{.Id}}&gt;{{else}}{{inline .Text}}: {{.Score}} points ({{percent .Percent}}){{end}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- range .Comments}}{{template "epub-comment" .}}{{end}}
+{{- if .More}}
+<p class="more">{{plural .More "more comment" "more comments"}} at <a href="{{itemurl .Id}}">{{itemurl .Id}}</a></p>
+{{- end}}
+</section>
+</body>
+</html>
+{{end}}
+
+{{- define "epub-comment"}}
+<div class="comment{{with .Status}} {{.}}{{end}}" id="c{{.Id}}">
+<p class="byline">{{if .Author}}<b>{{.Author}}</b>{{end}}{{if .Posted.ISO}} <time datetime="{{.Posted.ISO}}">{{.Posted.Text}}</time>{{end}}</p>
+<div class="text">{{.Body}}</div>
+{{- range .Replies}}{{template "epub-comment" .}}{{end}}
+{{- if .More}}
+<p class="more">{{plural .More "more reply" "more replies"}}</p>
+{{- end}}
+</div>
+{{- end}}`))
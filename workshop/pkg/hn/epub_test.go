@@ -0,0 +1,216 @@
+package hn_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type opfPackage struct {
+	Version  string `xml:"version,attr"`
+	UniqueID string `xml:"unique-identifier,attr"`
+	Metadata struct {
+		Identifiers []struct {
+			Id    string `xml:"id,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"identifier"`
+		Titles    []string `xml:"title"`
+		Languages []string `xml:"language"`
+		Creators  []string `xml:"creator"`
+		Meta      []struct {
+			Property string `xml:"property,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		Id         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IdRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// validateEPUB checks the structure of an EPUB 3 file: the stored mimetype
+// first, the container pointing at the package document, the required
+// metadata, a manifest whose files all exist and are well-formed, a spine
+// made of manifest items and a navigation document. It returns the files.
+func validateEPUB(t *testing.T, data []byte) (opfPackage, map[string]string) {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+	}
+	require.NotEmpty(t, z.File)
+	assert.Len(t, files, len(z.File), "duplicate file names")
+	assert.Equal(t, "mimetype", z.File[0].Name)
+	assert.Equal(t, zip.Store, z.File[0].Method)
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	assert.Equal(t, "mimetypeapplication/epub+zip", string(data[30:58]))
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(files["META-INF/container.xml"]), &container))
+	require.Len(t, container.Rootfiles, 1)
+	assert.Equal(t, "application/oebps-package+xml", container.Rootfiles[0].MediaType)
+	opfPath := container.Rootfiles[0].FullPath
+
+	var opf opfPackage
+	require.NoError(t, xml.Unmarshal([]byte(files[opfPath]), &opf))
+	assert.Equal(t, "3.0", opf.Version)
+	require.Len(t, opf.Metadata.Identifiers, 1)
+	assert.Equal(t, opf.UniqueID, opf.Metadata.Identifiers[0].Id)
+	assert.NotEmpty(t, opf.Metadata.Identifiers[0].Value)
+	assert.Len(t, opf.Metadata.Titles, 1)
+	assert.Len(t, opf.Metadata.Languages, 1)
+	modified := ""
+	for _, m := range opf.Metadata.Meta {
+		if m.Property == "dcterms:modified" {
+			modified = m.Value
+		}
+	}
+	_, err = time.Parse("2006-01-02T15:04:05Z", modified)
+	assert.NoError(t, err, "dcterms:modified %q", modified)
+
+	dir := path.Dir(opfPath)
+	ids := map[string]bool{}
+	nav := 0
+	for _, item := range opf.Manifest {
+		assert.False(t, ids[item.Id], "duplicate manifest id %s", item.Id)
+		ids[item.Id] = true
+		name := path.Join(dir, item.Href)
+		content, ok := files[name]
+		require.True(t, ok, "manifest item %s missing", name)
+		if item.MediaType == "application/xhtml+xml" {
+			wellFormed(t, name, content)
+		}
+		if item.Properties == "nav" {
+			nav++
+			assert.Contains(t, content, `epub:type="toc"`)
+		}
+	}
+	assert.Equal(t, 1, nav)
+	assert.Len(t, files, len(opf.Manifest)+3, "files outside the manifest")
+	require.NotEmpty(t, opf.Spine)
+	for _, ref := range opf.Spine {
+		assert.True(t, ids[ref.IdRef], "spine item %s not in the manifest", ref.IdRef)
+	}
+
+	return opf, files
+}
+
+func wellFormed(t *testing.T, name, content string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(content))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if !assert.NoError(t, err, name) {
+			return
+		}
+	}
+}
+
+func TestEPUBWriter(t *testing.T) {
+	t.Run("one story", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, hn.EPUBWriter{}.Write(&buf, renderStory()))
+
+		opf, files := validateEPUB(t, buf.Bytes())
+		assert.Equal(t, []string{"My YC app: Dropbox"}, opf.Metadata.Titles)
+		assert.Equal(t, []string{"en"}, opf.Metadata.Languages)
+		assert.Equal(t, []string{"dhouston"}, opf.Metadata.Creators)
+		assert.Equal(t, "urn:hn:8863", opf.Metadata.Identifiers[0].Value)
+		// The newest comment is two hours after the story.
+		assert.Contains(t, files["OEBPS/content.opf"], `<meta property="dcterms:modified">2007-04-04T21:16:40Z</meta>`)
+
+		chapter := files["OEBPS/story-8863.xhtml"]
+		assert.Contains(t, chapter, "<h1>My YC app: Dropbox</h1>")
+		assert.Contains(t, chapter, `<div class="comment" id="c9224">`)
+		assert.Contains(t, chapter, `<p class="byline"><b>dhouston</b> <time datetime="2007-04-04T21:16:40Z">2007-04-04 21:16 UTC</time></p>`)
+		assert.Contains(t, chapter, `<p class="more">1 more reply</p>`)
+		assert.Contains(t, chapter, `<div class="comment deleted" id="c8917">`)
+		assert.True(t, strings.Index(chapter, `id="c9479"`) < strings.Index(chapter, `id="c8917"`), "replies nest inside their parent")
+		assert.Contains(t, files["OEBPS/nav.xhtml"], `<li><a href="story-8863.xhtml">My YC app: Dropbox</a></li>`)
+	})
+
+	t.Run("several stories", func(t *testing.T) {
+		other := hn.Story{Id: 1, Author: "pg", Title: "Y Combinator & <friends>", Score: 57, Time: 1160418111}
+		modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		var buf bytes.Buffer
+		w := hn.EPUBWriter{Title: "Reading list", Language: "de", Modified: modified}
+		require.NoError(t, w.Write(&buf, other, renderStory()))
+
+		opf, files := validateEPUB(t, buf.Bytes())
+		assert.Equal(t, []string{"Reading list"}, opf.Metadata.Titles)
+		assert.Equal(t, []string{"de"}, opf.Metadata.Languages)
+		assert.Equal(t, []string{"pg", "dhouston"}, opf.Metadata.Creators)
+		assert.Equal(t, "story-1", opf.Spine[0].IdRef)
+		assert.Equal(t, "story-8863", opf.Spine[1].IdRef)
+		assert.Contains(t, files["OEBPS/content.opf"], "2020-01-02T03:04:05Z")
+		assert.Contains(t, files["OEBPS/story-1.xhtml"], "<h1>Y Combinator &amp; &lt;friends&gt;</h1>")
+
+		var again bytes.Buffer
+		require.NoError(t, w.Write(&again, other, renderStory()))
+		assert.Equal(t, buf.Bytes(), again.Bytes())
+	})
+
+	t.Run("poll options", func(t *testing.T) {
+		story := hn.Story{Id: 1, Title: "Poll", Poll: &hn.Poll{Options: []hn.PollOption{
+			{Id: 2, Text: "Don&#x27;t <i>know</i>", Score: 3, Percent: 60},
+		}}}
+
+		var buf bytes.Buffer
+		require.NoError(t, hn.EPUBWriter{}.Write(&buf, story))
+		_, files := validateEPUB(t, buf.Bytes())
+		assert.Contains(t, files["OEBPS/story-1.xhtml"], "<li>Don&#39;t <i>know</i>: 3 points (60.0%)</li>")
+	})
+
+	t.Run("story text", func(t *testing.T) {
+		story := hn.Story{Id: 20, Title: "Ask HN: The Arc Effect", Text: "Is it <i>really</i> worth it?"}
+
+		var buf bytes.Buffer
+		require.NoError(t, hn.EPUBWriter{}.Write(&buf, story))
+		_, files := validateEPUB(t, buf.Bytes())
+		assert.Contains(t, files["OEBPS/story-20.xhtml"], `<div class="text"><p>Is it <i>really</i> worth it?</p></div>`)
+	})
+
+	t.Run("duplicate stories", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, hn.EPUBWriter{}.Write(&buf, renderStory(), renderStory()))
+
+		opf, _ := validateEPUB(t, buf.Bytes())
+		assert.Equal(t, []string{"My YC app: Dropbox"}, opf.Metadata.Titles)
+		assert.Len(t, opf.Spine, 1)
+	})
+
+	t.Run("no stories", func(t *testing.T) {
+		assert.Error(t, hn.EPUBWriter{}.Write(ioutil.Discard))
+	})
+}
@@ -0,0 +1,294 @@
+package hn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"workshop-starter/pkg/hn/markup"
+)
+
+// FeedOptions describes a feed as a whole.
+type FeedOptions struct {
+	// Title of the feed, "Hacker News" when empty.
+	Title string
+	// Link to the site of the feed, the HN front page when empty.
+	Link string
+	// Description of the feed, required by RSS; the title when empty.
+	Description string
+	// FullText adds the sanitized text of items and the options of polls to
+	// the byline that is otherwise all an entry says.
+	FullText bool
+	// Updated is the time an Atom feed was last updated, now when zero.
+	Updated time.Time
+}
+
+func (o FeedOptions) withDefaults() FeedOptions {
+	if o.Title == "" {
+		o.Title = "Hacker News"
+	}
+	if o.Link == "" {
+		o.Link = "https://news.ycombinator.com/"
+	}
+	if o.Description == "" {
+		o.Description = o.Title
+	}
+
+	return o
+}
+
+// feedEntry holds what both feed formats show of an entry.
+type feedEntry struct {
+	title    string
+	link     string
+	comments string
+	summary  string
+	content  string
+	time     time.Time
+}
+
+func (o FeedOptions) entry(e Entry) feedEntry {
+	fe := feedEntry{
+		title:    e.Title,
+		link:     e.Url,
+		comments: itemURL(e.Id),
+		summary:  e.summary(),
+	}
+	if fe.title == "" {
+		kind := e.Type
+		if kind == "" {
+			kind = "item"
+		}
+		fe.title = fmt.Sprintf("%s by %s", strings.ToUpper(kind[:1])+kind[1:], e.Author)
+	}
+	if fe.link == "" {
+		fe.link = fe.comments
+	}
+	if e.Time != 0 {
+		fe.time = time.Unix(e.Time, 0).UTC()
+	}
+	if o.FullText {
+		var b strings.Builder
+		b.WriteString("<p>" + xmlEscape(fe.summary) + "</p>")
+		b.WriteString(markup.RenderHTML(markup.Parse(e.Text)))
+		if e.Poll != nil {
+			b.WriteString("<ol>")
+			for _, opt := range e.Poll.Options {
+				if opt.Failed {
+					b.WriteString(fmt.Sprintf("<li>&lt;failed %d&gt;</li>", opt.Id))
+					continue
+				}
+				b.WriteString(fmt.Sprintf("<li>%s: %d points (%.1f%%)</li>", markup.RenderInlineHTML(markup.Parse(opt.Text)), opt.Score, opt.Percent))
+			}
+			b.WriteString("</ol>")
+		}
+		fe.content = b.String()
+	}
+
+	return fe
+}
+
+// summary is the HN byline of an item.
+func (e Entry) summary() string {
+	s := fmt.Sprintf("%d points by %s", e.Score, e.Author)
+	if e.Type == "comment" {
+		s = "by " + e.Author
+	} else {
+		s += " | " + Plural(e.Descendants, "comment", "comments")
+	}
+
+	return s
+}
+
+type rssEncoder struct {
+	options FeedOptions
+}
+
+// NewRSSEncoder writes an RSS 2.0 feed with an item per entry. Items link to
+// their URL, or to their discussion when they have none, and use the
+// discussion URL as GUID and comments link.
+func NewRSSEncoder(options FeedOptions) Encoder {
+	return rssEncoder{options.withDefaults()}
+}
+
+type rssItem struct {
+	XMLName     xml.Name `xml:"item"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Comments    string   `xml:"comments"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func (r rssEncoder) Header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+<title>%s</title>
+<link>%s</link>
+<description>%s</description>
+`, xmlEscape(r.options.Title), xmlEscape(r.options.Link), xmlEscape(r.options.Description))
+
+	return err
+}
+
+func (r rssEncoder) Encode(w io.Writer, e Entry, n int) error {
+	fe := r.options.entry(e)
+	item := rssItem{
+		Title:       fe.title,
+		Link:        fe.link,
+		GUID:        rssGUID{IsPermaLink: true, Value: fe.comments},
+		Creator:     e.Author,
+		Comments:    fe.comments,
+		Description: fe.summary,
+	}
+	if !fe.time.IsZero() {
+		item.PubDate = fe.time.Format(time.RFC1123Z)
+	}
+	if fe.content != "" {
+		item.Description = fe.content
+	}
+
+	return writeXML(w, item)
+}
+
+func (r rssEncoder) Footer(w io.Writer, n int) error {
+	_, err := io.WriteString(w, "</channel>\n</rss>\n")
+	return err
+}
+
+type atomEncoder struct {
+	options FeedOptions
+}
+
+// NewAtomEncoder writes an Atom feed with an entry per Dump entry. Entries
+// are identified by their discussion URL, which is also their replies link.
+func NewAtomEncoder(options FeedOptions) Encoder {
+	return atomEncoder{options.withDefaults()}
+}
+
+type atomEntry struct {
+	XMLName   xml.Name     `xml:"entry"`
+	Title     string       `xml:"title"`
+	Links     []atomLink   `xml:"link"`
+	Id        string       `xml:"id"`
+	Published string       `xml:"published,omitempty"`
+	Updated   string       `xml:"updated"`
+	Author    *atomAuthor  `xml:"author,omitempty"`
+	Summary   string       `xml:"summary"`
+	Content   *atomContent `xml:"content,omitempty"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (a atomEncoder) Header(w io.Writer) error {
+	updated := a.options.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>%s</title>
+<subtitle>%s</subtitle>
+<link rel="alternate" href="%s"/>
+<id>%s</id>
+<updated>%s</updated>
+`, xmlEscape(a.options.Title), xmlEscape(a.options.Description), xmlEscape(a.options.Link),
+		xmlEscape(a.options.Link), updated.UTC().Format(time.RFC3339))
+
+	return err
+}
+
+func (a atomEncoder) Encode(w io.Writer, e Entry, n int) error {
+	fe := a.options.entry(e)
+	entry := atomEntry{
+		Title: fe.title,
+		Links: []atomLink{
+			{Rel: "alternate", Href: fe.link},
+			{Rel: "replies", Type: "text/html", Href: fe.comments},
+		},
+		Id:      fe.comments,
+		Updated: time.Unix(0, 0).UTC().Format(time.RFC3339),
+		Summary: fe.summary,
+	}
+	if !fe.time.IsZero() {
+		entry.Published = fe.time.Format(time.RFC3339)
+		entry.Updated = entry.Published
+	}
+	if e.Author != "" {
+		entry.Author = &atomAuthor{Name: e.Author, URI: "https://news.ycombinator.com/user?id=" + url.QueryEscape(e.Author)}
+	}
+	if fe.content != "" {
+		entry.Content = &atomContent{Type: "html", Value: fe.content}
+	}
+
+	return writeXML(w, entry)
+}
+
+func (a atomEncoder) Footer(w io.Writer, n int) error {
+	_, err := io.WriteString(w, "</feed>\n")
+	return err
+}
+
+// WriteRSS writes items as an RSS 2.0 feed.
+func WriteRSS(w io.Writer, options FeedOptions, items ...Item) error {
+	return encodeItems(w, NewRSSEncoder(options), items)
+}
+
+// WriteAtom writes items as an Atom feed.
+func WriteAtom(w io.Writer, options FeedOptions, items ...Item) error {
+	return encodeItems(w, NewAtomEncoder(options), items)
+}
+
+func encodeItems(w io.Writer, encoder Encoder, items []Item) error {
+	if err := encoder.Header(w); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if err := encoder.Encode(w, Entry{Item: item}, i); err != nil {
+			return err
+		}
+	}
+
+	return encoder.Footer(w, len(items))
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package hn_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func feedItems() []hn.Item {
+	return []hn.Item{
+		{Id: 8863, Type: "story", Title: "My YC app: Dropbox", Url: "http://www.getdropbox.com/u/2/screencast.html", Author: "dhouston", Score: 104, Descendants: 71, Time: 1175714200},
+		{Id: 121003, Type: "story", Title: "Ask HN: The Arc Effect", Text: "Is it <i>really</i> worth it?<script>x()</script>", Author: "tel", Score: 25, Descendants: 1, Time: 1203647620},
+	}
+}
+
+func TestRSSEncoder(t *testing.T) {
+	t.Run("summary", func(t *testing.T) {
+		var b bytes.Buffer
+		assert.NoError(t, hn.WriteRSS(&b, hn.FeedOptions{Title: "HN > 100"}, feedItems()[0]))
+
+		assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+<title>HN &gt; 100</title>
+<link>https://news.ycombinator.com/</link>
+<description>HN &gt; 100</description>
+<item>
+  <title>My YC app: Dropbox</title>
+  <link>http://www.getdropbox.com/u/2/screencast.html</link>
+  <guid isPermaLink="true">https://news.ycombinator.com/item?id=8863</guid>
+  <pubDate>Wed, 04 Apr 2007 19:16:40 +0000</pubDate>
+  <dc:creator>dhouston</dc:creator>
+  <comments>https://news.ycombinator.com/item?id=8863</comments>
+  <description>104 points by dhouston | 71 comments</description>
+</item>
+</channel>
+</rss>
+`, b.String())
+	})
+
+	t.Run("full text", func(t *testing.T) {
+		var b bytes.Buffer
+		assert.NoError(t, hn.WriteRSS(&b, hn.FeedOptions{FullText: true}, feedItems()...))
+
+		var rss struct {
+			Channel struct {
+				Items []struct {
+					Title       string `xml:"title"`
+					Link        string `xml:"link"`
+					GUID        string `xml:"guid"`
+					Description string `xml:"description"`
+				} `xml:"item"`
+			} `xml:"channel"`
+		}
+		assert.NoError(t, xml.Unmarshal(b.Bytes(), &rss))
+		assert.Len(t, rss.Channel.Items, 2)
+		ask := rss.Channel.Items[1]
+		assert.Equal(t, "https://news.ycombinator.com/item?id=121003", ask.Link)
+		assert.Equal(t, "https://news.ycombinator.com/item?id=121003", ask.GUID)
+		assert.Equal(t, "<p>25 points by tel | 1 comment</p><p>Is it <i>really</i> worth it?</p>", ask.Description)
+	})
+
+	t.Run("poll options", func(t *testing.T) {
+		poll := hn.Entry{
+			Item: hn.Item{Id: 126809, Type: "poll", Title: "Poll", Author: "pg"},
+			Poll: &hn.Poll{Options: []hn.PollOption{
+				{Id: 126810, Text: "Don&#x27;t <i>know</i>", Score: 3, Percent: 60},
+				{Id: 126811, Failed: true},
+			}},
+		}
+
+		var b bytes.Buffer
+		assert.NoError(t, hn.NewRSSEncoder(hn.FeedOptions{FullText: true}).Encode(&b, poll, 0))
+		assert.Contains(t, b.String(), "&lt;li&gt;Don&amp;#39;t &lt;i&gt;know&lt;/i&gt;: 3 points (60.0%)&lt;/li&gt;")
+		assert.Contains(t, b.String(), "&lt;li&gt;&amp;lt;failed 126811&amp;gt;&lt;/li&gt;")
+	})
+}
+
+func TestAtomEncoder(t *testing.T) {
+	updated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	comment := hn.Item{Id: 9224, Type: "comment", Author: "BrandonM", Text: "I have a few qualms", Time: 1175714300}
+
+	var b bytes.Buffer
+	assert.NoError(t, hn.WriteAtom(&b, hn.FeedOptions{Updated: updated, FullText: true}, feedItems()[1], comment))
+
+	var feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Id      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			Title string `xml:"title"`
+			Id    string `xml:"id"`
+			Links []struct {
+				Rel  string `xml:"rel,attr"`
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Author    string `xml:"author>name"`
+			Summary   string `xml:"summary"`
+			Content   struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	assert.NoError(t, xml.Unmarshal(b.Bytes(), &feed))
+	assert.Equal(t, "2020-01-02T03:04:05Z", feed.Updated)
+	assert.Len(t, feed.Entries, 2)
+
+	ask := feed.Entries[0]
+	assert.Equal(t, "https://news.ycombinator.com/item?id=121003", ask.Id)
+	assert.Equal(t, "2008-02-22T02:33:40Z", ask.Published)
+	assert.Equal(t, ask.Published, ask.Updated)
+	assert.Equal(t, "tel", ask.Author)
+	assert.Equal(t, "replies", ask.Links[1].Rel)
+	assert.Equal(t, "25 points by tel | 1 comment", ask.Summary)
+	assert.Equal(t, "html", ask.Content.Type)
+	assert.Contains(t, ask.Content.Value, "<p>Is it <i>really</i> worth it?</p>")
+	assert.NotContains(t, ask.Content.Value, "script")
+
+	assert.Equal(t, "Comment by BrandonM", feed.Entries[1].Title)
+	assert.Equal(t, "by BrandonM", feed.Entries[1].Summary)
+}
+
+func TestDumper_Feed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mock.NewMockClient(ctrl)
+	client.EXPECT().MaxItem().Return(8864, nil)
+	client.EXPECT().GetItem(8864).Return(hn.Item{Id: 8864, Type: "story", Title: "Low", Score: 1}, nil)
+	client.EXPECT().GetItem(8863).Return(feedItems()[0], nil)
+
+	rss, err := hn.NewEncoder("rss")
+	assert.NoError(t, err)
+	var b bytes.Buffer
+	filter := hn.WithFilter(hn.Filter{MinScore: 100})
+	assert.NoError(t, hn.NewDump(client, 1, hn.WithEncoder(rss), filter).Dump(&b))
+
+	var feed struct {
+		Titles []string `xml:"channel>item>title"`
+	}
+	assert.NoError(t, xml.Unmarshal(b.Bytes(), &feed))
+	assert.Equal(t, []string{"My YC app: Dropbox"}, feed.Titles)
+}
@@ -0,0 +1,132 @@
+package hn
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileStore keeps items in a directory, one <id>.json file per item. Files
+// saved from the API can be dropped in as they are. It implements Client, so
+// a StoryBuilder builds stories from a mirror as it would from the API.
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore returns a FileStore in dir, creating the directory.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(id int) string {
+	return filepath.Join(s.Dir, strconv.Itoa(id)+".json")
+}
+
+// GetItem reads a stored item. It returns ErrItemNotFound for items that
+// were never stored.
+func (s *FileStore) GetItem(id int) (Item, error) {
+	data, err := ioutil.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return Item{}, ErrItemNotFound
+	}
+	if err != nil {
+		return Item{}, err
+	}
+
+	var item Item
+	err = json.Unmarshal(data, &item)
+
+	return item, err
+}
+
+// MaxItem returns the largest stored ID, or 0 when the store is empty.
+func (s *FileStore) MaxItem() (int, error) {
+	ids, err := s.IDs()
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	return ids[len(ids)-1], nil
+}
+
+// IDs returns the stored IDs in ascending order.
+func (s *FileStore) IDs() ([]int, error) {
+	names, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, name := range names {
+		id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(name), ".json"))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+// Put stores an item and reports whether it changed. The file is replaced
+// atomically and left alone when the item is unchanged, so that its
+// modification time tells when the item last changed.
+func (s *FileStore) Put(item Item) (bool, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+	path := s.path(item.Id)
+	if old, err := ioutil.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return false, nil
+	}
+
+	tmp, err := ioutil.TempFile(s.Dir, ".item-")
+	if err != nil {
+		return false, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+
+	return true, nil
+}
+
+// StoringClient fetches items from Client and puts every item it gets into
+// Store, so building stories through it mirrors them.
+type StoringClient struct {
+	Client
+	Store *FileStore
+}
+
+// GetItem fetches an item and stores it.
+func (c StoringClient) GetItem(id int) (Item, error) {
+	item, err := c.Client.GetItem(id)
+	if err != nil {
+		return item, err
+	}
+	if _, err := c.Store.Put(item); err != nil {
+		return item, err
+	}
+
+	return item, nil
+}
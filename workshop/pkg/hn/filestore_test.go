@@ -0,0 +1,95 @@
+package hn_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("put and get", func(t *testing.T) {
+		store, err := hn.NewFileStore(filepath.Join(t.TempDir(), "items"))
+		assert.NoError(t, err)
+
+		item := hn.Item{Id: 2, Title: "Title 2", Score: 2}
+		changed, err := store.Put(item)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		changed, err = store.Put(item)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		item.Score = 20
+		changed, err = store.Put(item)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		_, err = store.Put(hn.Item{Id: 10})
+		assert.NoError(t, err)
+
+		got, err := store.GetItem(2)
+		assert.NoError(t, err)
+		assert.Equal(t, item, got)
+
+		ids, err := store.IDs()
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2, 10}, ids)
+		max, err := store.MaxItem()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, max)
+	})
+
+	t.Run("missing item", func(t *testing.T) {
+		store, err := hn.NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+
+		_, err = store.GetItem(1)
+		assert.Equal(t, hn.ErrItemNotFound, err)
+		max, err := store.MaxItem()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, max)
+	})
+
+	t.Run("reads API files", func(t *testing.T) {
+		dir := t.TempDir()
+		data, err := ioutil.ReadFile("testdata/item.json")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "8863.json"), data, 0644))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.json"), []byte("{}"), 0644))
+
+		store := &hn.FileStore{Dir: dir}
+		item, err := store.GetItem(8863)
+		assert.NoError(t, err)
+		assert.Equal(t, getItemFromTestData(t, "item"), item)
+		ids, err := store.IDs()
+		assert.NoError(t, err)
+		assert.Equal(t, []int{8863}, ids)
+	})
+}
+
+func TestStoringClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mock.NewMockClient(ctrl)
+	client.EXPECT().GetItem(8863).Return(getItemFromTestData(t, "item"), nil)
+	client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+	client.EXPECT().GetItem(8917).Return(hn.Item{}, hn.ErrItemNotFound)
+	client.EXPECT().GetItem(2922097).Return(getItemFromTestData(t, "child_3"), nil)
+
+	store, err := hn.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+	_, err = hn.NewStoryBuilder(hn.StoringClient{Client: client, Store: store}).Build(8863)
+	assert.NoError(t, err)
+
+	ids, err := store.IDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{8863, 2921983, 2922097}, ids)
+
+	_, err = os.Stat(filepath.Join(store.Dir, "8917.json"))
+	assert.True(t, os.IsNotExist(err))
+}
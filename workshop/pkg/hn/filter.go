@@ -0,0 +1,74 @@
+package hn
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter selects the items Dump writes. Zero fields match everything.
+type Filter struct {
+	// Types lists the accepted item types, such as "story" or "job".
+	Types    []string
+	MinScore int
+	// Authors, when set, is the list of the only accepted authors.
+	Authors        []string
+	ExcludeAuthors []string
+	// Domains accepts items linking to one of the domains or their
+	// subdomains. It implies HasURL.
+	Domains []string
+	HasURL  bool
+	// Since and Until bound the item time; Since is inclusive, Until is not.
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether item passes every condition of the filter.
+func (f Filter) Match(item Item) bool {
+	if len(f.Types) > 0 && !contains(f.Types, item.Type) {
+		return false
+	}
+	if item.Score < f.MinScore {
+		return false
+	}
+	if len(f.Authors) > 0 && !contains(f.Authors, item.Author) {
+		return false
+	}
+	if contains(f.ExcludeAuthors, item.Author) {
+		return false
+	}
+	if (f.HasURL || len(f.Domains) > 0) && item.Url == "" {
+		return false
+	}
+	if len(f.Domains) > 0 && !matchDomain(f.Domains, Domain(item.Url)) {
+		return false
+	}
+	if !f.Since.IsZero() && item.Time < f.Since.Unix() {
+		return false
+	}
+	if !f.Until.IsZero() && item.Time >= f.Until.Unix() {
+		return false
+	}
+
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchDomain(domains []string, host string) bool {
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(d), "www.")
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
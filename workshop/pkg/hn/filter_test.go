@@ -0,0 +1,118 @@
+package hn_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	story := hn.Item{
+		Id:     1,
+		Type:   "story",
+		Author: "pg",
+		Score:  50,
+		Url:    "https://blog.golang.org/go1.12",
+		Time:   time.Date(2019, 2, 25, 12, 0, 0, 0, time.UTC).Unix(),
+	}
+
+	cases := []struct {
+		name   string
+		filter hn.Filter
+		match  bool
+	}{
+		{"empty", hn.Filter{}, true},
+		{"type", hn.Filter{Types: []string{"story", "poll"}}, true},
+		{"other type", hn.Filter{Types: []string{"comment"}}, false},
+		{"min score", hn.Filter{MinScore: 50}, true},
+		{"score too low", hn.Filter{MinScore: 51}, false},
+		{"allowed author", hn.Filter{Authors: []string{"pg"}}, true},
+		{"not allowed author", hn.Filter{Authors: []string{"dhouston"}}, false},
+		{"excluded author", hn.Filter{ExcludeAuthors: []string{"pg"}}, false},
+		{"domain", hn.Filter{Domains: []string{"golang.org"}}, true},
+		{"exact domain", hn.Filter{Domains: []string{"blog.golang.org"}}, true},
+		{"other domain", hn.Filter{Domains: []string{"lang.org"}}, false},
+		{"has url", hn.Filter{HasURL: true}, true},
+		{"since", hn.Filter{Since: time.Date(2019, 2, 25, 0, 0, 0, 0, time.UTC)}, true},
+		{"before since", hn.Filter{Since: time.Date(2019, 2, 26, 0, 0, 0, 0, time.UTC)}, false},
+		{"until", hn.Filter{Until: time.Date(2019, 2, 26, 0, 0, 0, 0, time.UTC)}, true},
+		{"after until", hn.Filter{Until: time.Date(2019, 2, 25, 12, 0, 0, 0, time.UTC)}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.match, c.filter.Match(story))
+		})
+	}
+
+	t.Run("no url", func(t *testing.T) {
+		ask := hn.Item{Id: 2, Type: "story", Title: "Ask HN: ?"}
+		assert.False(t, hn.Filter{HasURL: true}.Match(ask))
+		assert.False(t, hn.Filter{Domains: []string{"golang.org"}}.Match(ask))
+	})
+
+	t.Run("upper case host", func(t *testing.T) {
+		item := hn.Item{Id: 3, Type: "story", Url: "http://WWW.Example.com/x"}
+		assert.True(t, hn.Filter{Domains: []string{"example.com"}}.Match(item))
+		assert.True(t, hn.Filter{Domains: []string{"Example.COM"}}.Match(item))
+	})
+}
+
+func TestDumper_Filter(t *testing.T) {
+	items := map[int]hn.Item{
+		10: {Id: 10, Type: "comment", Author: "a"},
+		9:  {Id: 9, Type: "story", Title: "Story 9", Score: 9},
+		8:  {Id: 8, Type: "comment", Author: "b"},
+		7:  {Id: 7, Type: "story", Title: "Story 7", Score: 1},
+		6:  {Id: 6, Type: "story", Title: "Story 6", Score: 6},
+	}
+
+	t.Run("limit counts matching items", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(10, nil)
+		expectItems(client, items, 10, 9, 8, 7, 6)
+
+		var b bytes.Buffer
+		filter := hn.Filter{Types: []string{"story"}, MinScore: 5}
+		err := hn.NewDump(client, 2, hn.WithFilter(filter)).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Story 9,9\nStory 6,6\n", b.String())
+	})
+
+	t.Run("scan cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(10, nil)
+		expectItems(client, items, 10, 9, 8)
+
+		var b bytes.Buffer
+		filter := hn.Filter{Types: []string{"story"}}
+		err := hn.NewDump(client, 5, hn.WithFilter(filter), hn.WithMaxScan(3)).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Story 9,9\n", b.String())
+	})
+
+	t.Run("stops at the first item", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(2, nil)
+		client.EXPECT().GetItem(2).Return(getItem(2), nil)
+		client.EXPECT().GetItem(1).Return(getItem(1), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDump(client, 5, hn.WithFilter(hn.Filter{})).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Title 2,2\nTitle 1,1\n", b.String())
+	})
+}
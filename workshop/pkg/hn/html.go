@@ -0,0 +1,199 @@
+package hn
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"time"
+	"workshop-starter/pkg/hn/markup"
+)
+
+// HTMLRenderer writes a Story as one self-contained HTML page: inline CSS,
+// no scripts, a <details> element per comment so that threads collapse, and
+// an anchor per comment for permalinks. The output only depends on the
+// story, so it can be archived and compared byte for byte.
+type HTMLRenderer struct {
+	// CollapseDepth, when set, renders the comments deeper than it closed.
+	CollapseDepth int
+	// UserURL links author names; to their HN profile when nil.
+	UserURL func(name string) string
+	// Home, when set, is linked above the title.
+	Home string
+}
+
+// htmlStory and htmlComment are what the page template is executed with.
+type htmlStory struct {
+	Story
+	Home      string
+	AuthorURL string
+	Posted    htmlTime
+	Body      template.HTML
+	Comments  []htmlComment
+	More      int
+}
+
+type htmlComment struct {
+	Id        int
+	Author    string
+	AuthorURL string
+	Posted    htmlTime
+	Body      template.HTML
+	Status    string
+	Open      bool
+	Replies   []htmlComment
+	More      int
+	Selected  bool
+}
+
+type htmlTime struct {
+	ISO  string
+	Text string
+}
+
+// Render writes the page for story to w.
+func (r HTMLRenderer) Render(w io.Writer, story Story) error {
+	page := htmlStory{
+		Story:     story,
+		Home:      r.Home,
+		AuthorURL: r.userURL(story.Author),
+		Posted:    newHTMLTime(story.Time),
+		Body:      storyBody(story),
+		More:      len(story.More),
+	}
+	page.Comments = r.comments(story.Comments, 1)
+
+	return pageTemplate.Execute(w, page)
+}
+
+func (r HTMLRenderer) comments(comments []Comment, depth int) []htmlComment {
+	var out []htmlComment
+	for _, c := range comments {
+		hc := htmlComment{
+			Id:        c.Id,
+			Author:    c.Author,
+			AuthorURL: r.userURL(c.Author),
+			Posted:    newHTMLTime(c.Time),
+			Open:      r.CollapseDepth <= 0 || depth <= r.CollapseDepth,
+			Replies:   r.comments(c.ChildComments, depth+1),
+			More:      len(c.More),
+			Selected:  c.Highlighted,
+		}
+		if c.Status != StatusOK {
+			hc.Status = c.Status.String()
+		}
+		// Bodies go through the markup sanitizer, which only lets the tags
+		// of HN through.
+		hc.Body = template.HTML(markup.RenderHTML(markup.Parse(c.Text)))
+		if c.Status != StatusOK && c.Text == "" {
+			hc.Body = template.HTML(template.HTMLEscapeString(DefaultPlaceholder(c)))
+		}
+		out = append(out, hc)
+	}
+
+	return out
+}
+
+// storyBody sanitizes the text of a story, which only Ask HN posts and polls
+// have.
+func storyBody(story Story) template.HTML {
+	if story.Text == "" {
+		return ""
+	}
+
+	return template.HTML(markup.RenderHTML(markup.Parse(story.Text)))
+}
+
+// inlineHTML sanitizes short HN markup, like poll options, for use inside
+// another element.
+func inlineHTML(text string) template.HTML {
+	return template.HTML(markup.RenderInlineHTML(markup.Parse(text)))
+}
+
+func (r HTMLRenderer) userURL(name string) string {
+	if r.UserURL != nil {
+		return r.UserURL(name)
+	}
+
+	return "https://news.ycombinator.com/user?id=" + url.QueryEscape(name)
+}
+
+func newHTMLTime(t int64) htmlTime {
+	if t == 0 {
+		return htmlTime{}
+	}
+	at := time.Unix(t, 0).UTC()
+
+	return htmlTime{ISO: at.Format(time.RFC3339), Text: at.Format("2006-01-02 15:04 UTC")}
+}
+
+// htmlFuncs are the template functions shared by the HTML and EPUB exports.
+var htmlFuncs = template.FuncMap{
+	"itemurl": itemURL,
+	"plural":  Plural,
+	"percent": func(f float64) string { return fmt.Sprintf("%.1f%%", f) },
+	"inline":  inlineHTML,
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0 auto; max-width: 50em; padding: 1em; font: 15px/1.4 Verdana, Geneva, sans-serif; color: #222; background: #f6f6ef; }
+a { color: inherit; }
+nav { font-size: .85em; margin-bottom: 1em; }
+h1 { font-size: 1.4em; margin: 0 0 .2em; }
+.meta, summary { color: #828282; font-size: .85em; }
+.poll li { margin: .2em 0; }
+details.comment { margin: .8em 0 0 0; padding-left: .8em; border-left: 2px solid #ddd; }
+details.comment details.comment { margin-left: .6em; }
+summary { cursor: pointer; }
+summary .author { font-weight: bold; color: #555; text-decoration: none; }
+.permalink { text-decoration: none; }
+.text p { margin: .4em 0; }
+.text pre { overflow-x: auto; background: #eee; padding: .4em; }
+.text blockquote { margin: .4em 0; padding-left: .6em; border-left: 3px solid #ccc; color: #555; }
+.deleted > .text, .dead > .text, .failed > .text { color: #aaa; font-style: italic; }
+.selected > summary { background: #ffc; }
+.more { font-size: .85em; }
+</style>
+</head>
+<body>
+{{- with .Home}}
+<nav><a href="{{.}}">Home</a></nav>
+{{- end}}
+<article id="item-{{.Id}}">
+<h1>{{if .Url}}<a href="{{.Url}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h1>
+<p class="meta">{{.Score}} points by <a href="{{.AuthorURL}}">{{.Author}}</a>{{if .Posted.ISO}} <time datetime="{{.Posted.ISO}}">{{.Posted.Text}}</time>{{end}} | <a href="{{itemurl .Id}}">{{plural .Descendants "comment" "comments"}}</a></p>
+{{- with .Body}}
+<div class="text">{{.}}</div>
+{{- end}}
+{{- with .Poll}}
+<ol class="poll">
+{{- range .Options}}
+<li>{{if .Failed}}&lt;failed {{.Id}}&gt;{{else}}{{inline .Text}}: {{.Score}} points ({{percent .Percent}}){{end}}</li>
+{{- end}}
+</ol>
+{{- end}}
+<section class="comments">
+{{- range .Comments}}{{template "comment" .}}{{end}}
+{{- if .More}}
+<p class="more"><a href="{{itemurl .Id}}">{{plural .More "more comment" "more comments"}}</a></p>
+{{- end}}
+</section>
+</article>
+</body>
+</html>
+{{define "comment"}}
+<details class="comment{{with .Status}} {{.}}{{end}}{{if .Selected}} selected{{end}}" id="c{{.Id}}"{{if .Open}} open{{end}}>
+<summary>{{if .Author}}<a class="author" href="{{.AuthorURL}}">{{.Author}}</a>{{end}}{{if .Posted.ISO}} <time datetime="{{.Posted.ISO}}">{{.Posted.Text}}</time>{{end}} <a class="permalink" href="#c{{.Id}}">#</a></summary>
+<div class="text">{{.Body}}</div>
+{{- range .Replies}}{{template "comment" .}}{{end}}
+{{- if .More}}
+<p class="more"><a href="{{itemurl .Id}}">{{plural .More "more reply" "more replies"}}</a></p>
+{{- end}}
+</details>
+{{- end}}`))
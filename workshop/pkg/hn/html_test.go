@@ -0,0 +1,100 @@
+package hn_test
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestHTMLRenderer(t *testing.T) {
+	t.Run("golden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(8863).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_2"), nil)
+		client.EXPECT().GetItem(2922097).Return(getItemFromTestData(t, "child_3"), nil)
+
+		story, err := hn.NewStoryBuilder(client).Build(8863)
+		assert.NoError(t, err)
+		story.Comments = append(story.Comments, renderStory().Comments...)
+
+		var buf bytes.Buffer
+		assert.NoError(t, hn.HTMLRenderer{}.Render(&buf, story))
+
+		if *update {
+			assert.NoError(t, ioutil.WriteFile("testdata/story.html", buf.Bytes(), 0644))
+		}
+		golden, err := ioutil.ReadFile("testdata/story.html")
+		assert.NoError(t, err)
+		assert.Equal(t, string(golden), buf.String())
+
+		var again bytes.Buffer
+		assert.NoError(t, hn.HTMLRenderer{}.Render(&again, story))
+		assert.Equal(t, buf.String(), again.String())
+	})
+
+	t.Run("sanitizes bodies", func(t *testing.T) {
+		story := hn.Story{
+			Id:    1,
+			Title: "<b>Title</b>",
+			Comments: []hn.Comment{{
+				Id:     2,
+				Author: `"><script>`,
+				Text:   `hi<script>alert(1)</script> <a href="javascript:alert(1)">x</a> <a href="https://example.com/" onclick="x()">y</a>`,
+			}},
+		}
+
+		var buf bytes.Buffer
+		assert.NoError(t, hn.HTMLRenderer{}.Render(&buf, story))
+		out := buf.String()
+
+		assert.NotContains(t, out, "<script")
+		assert.NotContains(t, out, "javascript:")
+		assert.NotContains(t, out, "onclick")
+		assert.Contains(t, out, "<title>&lt;b&gt;Title&lt;/b&gt;</title>")
+		assert.Contains(t, out, `<div class="text"><p>hi x <a href="https://example.com/" rel="nofollow">y</a></p></div>`)
+	})
+
+	t.Run("poll options", func(t *testing.T) {
+		story := hn.Story{Id: 1, Title: "Poll", Poll: &hn.Poll{Options: []hn.PollOption{
+			{Id: 2, Text: "Don&#x27;t <i>know</i><script>x()</script>", Score: 3, Percent: 60},
+			{Id: 3, Failed: true},
+		}}}
+
+		var buf bytes.Buffer
+		assert.NoError(t, hn.HTMLRenderer{}.Render(&buf, story))
+		assert.Contains(t, buf.String(), "<li>Don&#39;t <i>know</i>: 3 points (60.0%)</li>")
+		assert.Contains(t, buf.String(), "<li>&lt;failed 3&gt;</li>")
+	})
+
+	t.Run("story text", func(t *testing.T) {
+		story := hn.Story{Id: 20, Title: "Ask HN: The Arc Effect", Text: "Is it <i>really</i> worth it?<script>x()</script>"}
+
+		var buf bytes.Buffer
+		assert.NoError(t, hn.HTMLRenderer{}.Render(&buf, story))
+		assert.Contains(t, buf.String(), `<div class="text"><p>Is it <i>really</i> worth it?</p></div>`)
+	})
+
+	t.Run("collapse depth", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, hn.HTMLRenderer{CollapseDepth: 1}.Render(&buf, renderStory()))
+		out := buf.String()
+
+		assert.Contains(t, out, `<details class="comment" id="c9224" open>`)
+		assert.Contains(t, out, `<details class="comment" id="c9479">`)
+		assert.Contains(t, out, `<details class="comment deleted" id="c8917" open>`)
+		assert.Equal(t, 1, strings.Count(out, `href="#c9479"`))
+	})
+}
@@ -0,0 +1,50 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+)
+
+// StoryList names one of the story lists the API publishes.
+type StoryList string
+
+const (
+	TopStories  StoryList = "top"
+	NewStories  StoryList = "new"
+	BestStories StoryList = "best"
+	AskStories  StoryList = "ask"
+	ShowStories StoryList = "show"
+	JobStories  StoryList = "job"
+)
+
+// StoryLists are all the lists GetStories accepts.
+var StoryLists = []StoryList{TopStories, NewStories, BestStories, AskStories, ShowStories, JobStories}
+
+// GetStories returns the item IDs of a story list, in the order the site
+// shows them.
+func (s *HackerNewsClient) GetStories(list StoryList) ([]int, error) {
+	return s.GetStoriesContext(context.Background(), list)
+}
+
+func (s *HackerNewsClient) GetStoriesContext(ctx context.Context, list StoryList) ([]int, error) {
+	if !list.valid() {
+		return nil, fmt.Errorf("unknown story list %q", list)
+	}
+
+	var ids []int
+	if err := s.getJSON(ctx, "/"+string(list)+"stories.json", &ids); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (l StoryList) valid() bool {
+	for _, known := range StoryLists {
+		if l == known {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+package hn_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"workshop-starter/pkg/hn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClient_GetStories(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/askstories.json", r.URL.Path)
+			_, _ = w.Write([]byte(`[121003,8863]`))
+		}))
+		defer ts.Close()
+
+		ids, err := hn.NewHTTPClientFor(ts.URL).GetStories(hn.AskStories)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{121003, 8863}, ids)
+	})
+
+	t.Run("Unknown list", func(t *testing.T) {
+		_, err := hn.NewHTTPClientFor("").GetStories("worst")
+
+		assert.Error(t, err)
+	})
+}
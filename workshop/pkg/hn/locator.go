@@ -0,0 +1,142 @@
+package hn
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultProbeTolerance is how many consecutive IDs without a time a probe
+// steps over before giving up.
+const defaultProbeTolerance = 20
+
+// Locator finds item IDs by time. It relies on IDs growing with the time
+// items were posted, binary searching the ID space with GetItem probes.
+// Probe results are cached, so repeated lookups get cheaper; MaxItem is asked
+// again on every lookup so that new items are found. A Locator is safe for
+// concurrent use.
+type Locator struct {
+	client    Client
+	tolerance int
+
+	mu      sync.Mutex
+	times   map[int]int64
+	missing map[int]bool
+}
+
+func NewLocator(client Client) *Locator {
+	return &Locator{
+		client:    client,
+		tolerance: defaultProbeTolerance,
+		times:     map[int]int64{},
+		missing:   map[int]bool{},
+	}
+}
+
+// IDAt returns the first ID posted at or after t. It returns MaxItem+1 when
+// t is later than the newest item.
+func (l *Locator) IDAt(t time.Time) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	maxItem, err := l.client.MaxItem()
+	if err != nil {
+		return 0, err
+	}
+
+	target := t.Unix()
+	lo, hi := 1, maxItem+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		id, at, err := l.probe(mid, hi)
+		if err != nil {
+			return 0, err
+		}
+		if id == 0 {
+			// Nothing with a time between mid and hi: those IDs belong
+			// with whatever comes after them.
+			hi = mid
+			continue
+		}
+		if at >= target {
+			hi = mid
+		} else {
+			lo = id + 1
+		}
+	}
+
+	return lo, nil
+}
+
+// probe returns the first ID from id on, below hi, that has a time. Deleted
+// items still carry one; missing IDs are remembered and stepped over up to
+// the tolerance. Other errors are returned, as they may not happen again.
+// It returns a zero ID when it reaches hi first.
+func (l *Locator) probe(id, hi int) (int, int64, error) {
+	var lastErr error
+	for skipped := 0; id < hi; id++ {
+		if at, ok := l.times[id]; ok {
+			return id, at, nil
+		}
+		if !l.missing[id] {
+			item, err := l.client.GetItem(id)
+			if err == nil && item.Time != 0 {
+				l.times[id] = item.Time
+				return id, item.Time, nil
+			}
+			if err != nil && !errors.Is(err, ErrItemNotFound) {
+				return 0, 0, err
+			}
+			if err == nil {
+				err = ErrItemNotFound
+			}
+			l.missing[id] = true
+			lastErr = err
+		}
+		if skipped++; skipped > l.tolerance {
+			return 0, 0, fmt.Errorf("no item with a time around %d: %v", id, lastErr)
+		}
+	}
+
+	return 0, 0, nil
+}
+
+type timeRange struct {
+	locator  *Locator
+	from, to time.Time
+	ids      IDSource
+}
+
+// TimeRange yields, in ascending order, the IDs of the items posted from
+// from up to, but not including, to. The IDs are located on the first call
+// to Next.
+func TimeRange(locator *Locator, from, to time.Time) IDSource {
+	return &timeRange{locator: locator, from: from, to: to}
+}
+
+func (r *timeRange) Next() (int, bool, error) {
+	if r.ids == nil {
+		first, err := r.locator.IDAt(r.from)
+		if err != nil {
+			return 0, false, err
+		}
+		end, err := r.locator.IDAt(r.to)
+		if err != nil {
+			return 0, false, err
+		}
+		if end > first {
+			r.ids = IDRange(first, end-1)
+		} else {
+			r.ids = IDList()
+		}
+	}
+
+	return r.ids.Next()
+}
+
+// NewTimeDump dumps the items posted in the window [from, to), in ascending
+// order.
+func NewTimeDump(client Client, from, to time.Time, opts ...DumpOption) *Dump {
+	return NewDumpFrom(client, TimeRange(NewLocator(client), from, to), opts...)
+}
@@ -0,0 +1,143 @@
+package hn_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// timedClient serves items 1..max posted ten seconds apart from epoch, with
+// the missing IDs answering ErrItemNotFound and the failing ones another
+// error until their count runs out. It counts GetItem calls.
+type timedClient struct {
+	max     int
+	missing map[int]bool
+	failing map[int]int
+	calls   int
+}
+
+var epoch = time.Unix(1600000000, 0)
+
+func (c *timedClient) MaxItem() (int, error) {
+	return c.max, nil
+}
+
+func (c *timedClient) GetItem(id int) (hn.Item, error) {
+	c.calls++
+	if c.failing[id] > 0 {
+		c.failing[id]--
+		return hn.Item{}, errors.New("connection reset")
+	}
+	if id < 1 || id > c.max || c.missing[id] {
+		return hn.Item{}, hn.ErrItemNotFound
+	}
+
+	return hn.Item{Id: id, Title: "Title", Time: epoch.Unix() + int64(id)*10}, nil
+}
+
+func at(id int) time.Time {
+	return epoch.Add(time.Duration(id*10) * time.Second)
+}
+
+func TestLocator_IDAt(t *testing.T) {
+	t.Run("exact and between items", func(t *testing.T) {
+		locator := hn.NewLocator(&timedClient{max: 1000})
+
+		id, err := locator.IDAt(at(421))
+		assert.NoError(t, err)
+		assert.Equal(t, 421, id)
+
+		id, err = locator.IDAt(at(421).Add(-5 * time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, 421, id)
+	})
+
+	t.Run("outside the ID space", func(t *testing.T) {
+		locator := hn.NewLocator(&timedClient{max: 1000})
+
+		id, err := locator.IDAt(epoch)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, id)
+
+		id, err = locator.IDAt(at(5000))
+		assert.NoError(t, err)
+		assert.Equal(t, 1001, id)
+	})
+
+	t.Run("missing probes", func(t *testing.T) {
+		missing := map[int]bool{}
+		for id := 400; id < 600; id += 1 {
+			if id%7 != 0 {
+				missing[id] = true
+			}
+		}
+		locator := hn.NewLocator(&timedClient{max: 1000, missing: missing})
+
+		id, err := locator.IDAt(at(500))
+		assert.NoError(t, err)
+		assert.Equal(t, 498, id, "first ID after the last item posted before")
+	})
+
+	t.Run("too many missing", func(t *testing.T) {
+		missing := map[int]bool{}
+		for id := 1; id <= 1000; id++ {
+			missing[id] = true
+		}
+		locator := hn.NewLocator(&timedClient{max: 1000, missing: missing})
+
+		_, err := locator.IDAt(at(500))
+		assert.Error(t, err)
+	})
+
+	t.Run("probes are cached", func(t *testing.T) {
+		client := &timedClient{max: 1000}
+		locator := hn.NewLocator(client)
+
+		_, err := locator.IDAt(at(421))
+		assert.NoError(t, err)
+		calls := client.calls
+
+		_, err = locator.IDAt(at(421))
+		assert.NoError(t, err)
+		assert.Equal(t, calls, client.calls)
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		client := &timedClient{max: 1000, failing: map[int]int{501: 1}}
+		locator := hn.NewLocator(client)
+
+		_, err := locator.IDAt(at(421))
+		assert.Error(t, err)
+
+		id, err := locator.IDAt(at(421))
+		assert.NoError(t, err)
+		assert.Equal(t, 421, id)
+	})
+
+	t.Run("new items", func(t *testing.T) {
+		client := &timedClient{max: 1000}
+		locator := hn.NewLocator(client)
+
+		id, err := locator.IDAt(at(1200))
+		assert.NoError(t, err)
+		assert.Equal(t, 1001, id)
+
+		client.max = 2000
+		id, err = locator.IDAt(at(1200))
+		assert.NoError(t, err)
+		assert.Equal(t, 1200, id)
+	})
+}
+
+func TestDumper_TimeWindow(t *testing.T) {
+	client := &timedClient{max: 1000}
+
+	var b bytes.Buffer
+	err := hn.NewTimeDump(client, at(10), at(13)).Dump(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "Title,0\nTitle,0\nTitle,0\n", b.String())
+}
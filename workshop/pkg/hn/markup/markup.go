@@ -0,0 +1,311 @@
+// Package markup parses the restricted HTML of Hacker News comments and item
+// texts into a small document tree, and renders that tree as plain text,
+// Markdown, ANSI-styled text or sanitized HTML.
+//
+// HN only emits <p>, <i>, <a href>, <pre><code> and character references.
+// Anything else is dropped, keeping its text, so every renderer produces
+// output that is safe to embed again.
+package markup
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Kind tells what a Node is.
+type Kind int
+
+const (
+	// Document is the root. Its children are blocks.
+	Document Kind = iota
+	// Paragraph, Quote and CodeBlock are blocks. Quote is a paragraph that
+	// starts with ">", the HN convention for quoting.
+	Paragraph
+	Quote
+	CodeBlock
+	// Text, Italic, Code and Link are inline nodes.
+	Text
+	Italic
+	Code
+	Link
+)
+
+// Node is an element of a parsed document. Text holds the content of Text
+// and CodeBlock nodes, Href the target of Link nodes.
+type Node struct {
+	Kind     Kind
+	Text     string
+	Href     string
+	Children []*Node
+}
+
+// dropped are the tags whose content is dropped along with them.
+var dropped = map[string]bool{"script": true, "style": true, "iframe": true, "object": true, "template": true}
+
+// Parse parses HN markup. It never fails: unknown tags are removed and
+// unclosed ones are closed at the end of their block.
+func Parse(s string) *Node {
+	p := &parser{doc: &Node{Kind: Document}}
+	for s != "" {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			p.text(s)
+			break
+		}
+		p.text(s[:i])
+		j := strings.IndexByte(s[i:], '>')
+		if j < 0 {
+			p.text(s[i:])
+			break
+		}
+		s = p.tag(s[i+1:i+j], s[i+j+1:])
+	}
+	p.endBlock()
+	quotes(p.doc)
+
+	return p.doc
+}
+
+// parser builds a document. The inline stack holds the open inline nodes of
+// the current paragraph, which is at its bottom.
+type parser struct {
+	doc    *Node
+	inline []*Node
+	// unsafeLinks counts the open <a> tags whose target was dropped.
+	unsafeLinks int
+}
+
+func (p *parser) text(raw string) {
+	text := clean(html.UnescapeString(raw), false)
+	if text == "" {
+		return
+	}
+	p.append(&Node{Kind: Text, Text: text})
+}
+
+// append adds an inline node to the innermost open node, starting a
+// paragraph when none is open.
+func (p *parser) append(n *Node) {
+	if len(p.inline) == 0 {
+		para := &Node{Kind: Paragraph}
+		p.inline = []*Node{para}
+	}
+	top := p.inline[len(p.inline)-1]
+	top.Children = append(top.Children, n)
+}
+
+func (p *parser) endBlock() {
+	if len(p.inline) > 0 && hasText(p.inline[0]) {
+		p.doc.Children = append(p.doc.Children, p.inline[0])
+	}
+	p.inline = nil
+}
+
+// open pushes an inline node.
+func (p *parser) open(n *Node) {
+	p.append(n)
+	p.inline = append(p.inline, n)
+}
+
+// close pops the innermost open inline node of the given kind, with
+// everything opened after it.
+func (p *parser) close(kind Kind) {
+	for i := len(p.inline) - 1; i > 0; i-- {
+		if p.inline[i].Kind == kind {
+			p.inline = p.inline[:i]
+			return
+		}
+	}
+}
+
+// tag handles the tag between < and > and returns the input after it.
+func (p *parser) tag(tag, rest string) string {
+	name, closing := tagName(tag)
+	switch {
+	case name == "p":
+		p.endBlock()
+	case name == "pre" && !closing:
+		p.endBlock()
+		return p.pre(rest)
+	case name == "i" || name == "em":
+		if closing {
+			p.close(Italic)
+		} else {
+			p.open(&Node{Kind: Italic})
+		}
+	case name == "code":
+		if closing {
+			p.close(Code)
+		} else {
+			p.open(&Node{Kind: Code})
+		}
+	case name == "a" && closing:
+		if p.unsafeLinks > 0 {
+			p.unsafeLinks--
+		} else {
+			p.close(Link)
+		}
+	case name == "a":
+		if href := safeURL(attr(tag, "href")); href != "" {
+			p.open(&Node{Kind: Link, Href: href})
+		} else {
+			p.unsafeLinks++
+		}
+	case dropped[name] && !closing:
+		end := strings.Index(strings.ToLower(rest), "</"+name)
+		if end < 0 {
+			return ""
+		}
+		return rest[end:]
+	}
+
+	return rest
+}
+
+// pre reads a code block up to </pre>. Tags inside it are dropped.
+func (p *parser) pre(s string) string {
+	end := strings.Index(strings.ToLower(s), "</pre")
+	body, rest := s, ""
+	if end >= 0 {
+		body = s[:end]
+		rest = s[end:]
+		if gt := strings.IndexByte(rest, '>'); gt >= 0 {
+			rest = rest[gt+1:]
+		} else {
+			rest = ""
+		}
+	}
+
+	var code strings.Builder
+	for body != "" {
+		i := strings.IndexByte(body, '<')
+		if i < 0 {
+			code.WriteString(body)
+			break
+		}
+		code.WriteString(body[:i])
+		j := strings.IndexByte(body[i:], '>')
+		if j < 0 {
+			code.WriteString(body[i:])
+			break
+		}
+		body = body[i+j+1:]
+	}
+	text := strings.Trim(clean(html.UnescapeString(code.String()), true), "\n")
+	if strings.TrimSpace(text) != "" {
+		p.doc.Children = append(p.doc.Children, &Node{Kind: CodeBlock, Text: text})
+	}
+
+	return rest
+}
+
+// tagName returns the lower case name of a tag and whether it closes.
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == '/' })
+	if end >= 0 {
+		tag = tag[:end]
+	}
+
+	return strings.ToLower(tag), closing
+}
+
+// attr returns the value of an attribute of a tag, quoted or not.
+func attr(tag, name string) string {
+	lower := strings.ToLower(tag)
+	i := strings.Index(lower, " "+name+"=")
+	if i < 0 {
+		return ""
+	}
+	value := tag[i+len(name)+2:]
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return html.UnescapeString(value[1 : end+1])
+		}
+		return html.UnescapeString(value[1:])
+	}
+	if end := strings.IndexFunc(value, unicode.IsSpace); end >= 0 {
+		value = value[:end]
+	}
+
+	return html.UnescapeString(value)
+}
+
+// safeURL returns href when it is a web or mail link, and "" otherwise.
+func safeURL(href string) string {
+	href = strings.TrimSpace(href)
+	lower := strings.ToLower(href)
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return clean(href, false)
+		}
+	}
+
+	return ""
+}
+
+// Clean removes the control characters of plain text, such as titles and
+// user names, that is shown on a terminal. Line breaks and tabs become
+// spaces.
+func Clean(s string) string {
+	return clean(s, false)
+}
+
+// clean removes control characters, which could drive a terminal. Line
+// breaks and tabs are kept in code and become spaces elsewhere.
+func clean(s string, code bool) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			if code {
+				return r
+			}
+			return ' '
+		case r == '\r':
+			return -1
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func hasText(n *Node) bool {
+	if strings.TrimSpace(n.Text) != "" {
+		return true
+	}
+	for _, c := range n.Children {
+		if hasText(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quotes turns the paragraphs starting with ">" into quotes.
+func quotes(doc *Node) {
+	for _, block := range doc.Children {
+		if block.Kind != Paragraph {
+			continue
+		}
+		parent, first := block, block
+		for len(first.Children) > 0 {
+			parent, first = first, first.Children[0]
+		}
+		if first.Kind != Text {
+			continue
+		}
+		trimmed := strings.TrimLeft(first.Text, " ")
+		if !strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		block.Kind = Quote
+		first.Text = strings.TrimLeft(strings.TrimPrefix(trimmed, ">"), " ")
+		if first.Text == "" {
+			parent.Children = parent.Children[1:]
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package markup_test
+
+import (
+	"testing"
+	"workshop-starter/pkg/hn/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func text(s string) *markup.Node {
+	return &markup.Node{Kind: markup.Text, Text: s}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("paragraphs and inline markup", func(t *testing.T) {
+		doc := markup.Parse(`Fish &amp; <i>chips</i><p>See <a href="https://example.com/x" rel="nofollow">https://example.com/x</a>`)
+		assert.Equal(t, &markup.Node{Kind: markup.Document, Children: []*markup.Node{
+			{Kind: markup.Paragraph, Children: []*markup.Node{
+				text("Fish & "),
+				{Kind: markup.Italic, Children: []*markup.Node{text("chips")}},
+			}},
+			{Kind: markup.Paragraph, Children: []*markup.Node{
+				text("See "),
+				{Kind: markup.Link, Href: "https://example.com/x", Children: []*markup.Node{text("https://example.com/x")}},
+			}},
+		}}, doc)
+	})
+
+	t.Run("code block", func(t *testing.T) {
+		doc := markup.Parse("Try:<p><pre><code>  if a &lt; b {\n    <i>x</i>()\n  }\n</code></pre>")
+		assert.Len(t, doc.Children, 2)
+		assert.Equal(t, &markup.Node{Kind: markup.CodeBlock, Text: "  if a < b {\n    x()\n  }"}, doc.Children[1])
+	})
+
+	t.Run("quote", func(t *testing.T) {
+		doc := markup.Parse("&gt; <i>quoted</i> text<p>reply")
+		assert.Equal(t, markup.Quote, doc.Children[0].Kind)
+		assert.Equal(t, markup.Paragraph, doc.Children[1].Kind)
+		assert.Equal(t, []*markup.Node{
+			{Kind: markup.Italic, Children: []*markup.Node{text("quoted")}},
+			text(" text"),
+		}, doc.Children[0].Children)
+	})
+
+	t.Run("sanitizing", func(t *testing.T) {
+		doc := markup.Parse(`<b>bold</b> <script>alert(1)</script><a href="javascript:alert(1)">click</a> <span onclick="x">me</span>` + "\x1b[31m")
+		assert.Equal(t, []*markup.Node{
+			{Kind: markup.Paragraph, Children: []*markup.Node{text("bold"), text(" "), text("click"), text(" "), text("me"), text("[31m")}},
+		}, doc.Children)
+	})
+
+	t.Run("unclosed tags", func(t *testing.T) {
+		doc := markup.Parse("<i>open<p>next")
+		assert.Len(t, doc.Children, 2)
+		assert.Equal(t, []*markup.Node{text("next")}, doc.Children[1].Children)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Empty(t, markup.Parse("").Children)
+		assert.Empty(t, markup.Parse("<p> <p>").Children)
+	})
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "Ask HN: [31mred line two", markup.Clean("Ask HN: \x1b[31mred\r\nline\ttwo"))
+}
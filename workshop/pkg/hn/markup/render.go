@@ -0,0 +1,351 @@
+package markup
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiDim       = "\x1b[2m"
+)
+
+// RenderText renders doc as plain text wrapped at width runes, or not
+// wrapped when width is zero. Paragraphs are separated by a blank line,
+// quotes are prefixed with "> ", code blocks are indented by two spaces and
+// links read as their URL.
+func RenderText(doc *Node, width int) string {
+	return renderLines(doc, width, false)
+}
+
+// RenderANSI renders doc like RenderText, with italics, underlined links
+// and dimmed code in ANSI escape codes.
+func RenderANSI(doc *Node, width int) string {
+	return renderLines(doc, width, true)
+}
+
+func renderLines(doc *Node, width int, ansi bool) string {
+	var lines []string
+	for i, block := range doc.Children {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		switch block.Kind {
+		case CodeBlock:
+			for _, l := range strings.Split(block.Text, "\n") {
+				l = "  " + l
+				if ansi {
+					l = ansiDim + l + ansiReset
+				}
+				lines = append(lines, strings.TrimRight(l, " "))
+			}
+		case Quote:
+			quote := "> "
+			if ansi {
+				quote = ansiDim + ">" + ansiReset + " "
+			}
+			w := width - 2
+			if width > 0 && w < 1 {
+				w = 1
+			}
+			for _, l := range wrap(words(block.Children, ansi), w) {
+				lines = append(lines, quote+l)
+			}
+		default:
+			lines = append(lines, wrap(words(block.Children, ansi), width)...)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// segment is a run of text in one style within a word.
+type segment struct {
+	text  string
+	style string
+}
+
+// word is what wrapping never breaks: text between spaces, possibly
+// spanning several styles.
+type word []segment
+
+func (w word) width() int {
+	n := 0
+	for _, s := range w {
+		n += utf8.RuneCountInString(s.text)
+	}
+
+	return n
+}
+
+func (w word) String() string {
+	var b strings.Builder
+	for _, s := range w {
+		if s.style == "" {
+			b.WriteString(s.text)
+		} else {
+			b.WriteString(s.style + s.text + ansiReset)
+		}
+	}
+
+	return b.String()
+}
+
+// words splits inline nodes at white space. Styles are only recorded when
+// ansi is set.
+func words(nodes []*Node, ansi bool) []word {
+	var ws []word
+	var current word
+	add := func(text, style string) {
+		for _, r := range text {
+			if unicode.IsSpace(r) {
+				if len(current) > 0 {
+					ws = append(ws, current)
+					current = nil
+				}
+				continue
+			}
+			if n := len(current); n > 0 && current[n-1].style == style {
+				current[n-1].text += string(r)
+			} else {
+				current = append(current, segment{text: string(r), style: style})
+			}
+		}
+	}
+
+	var walk func(nodes []*Node, style string)
+	walk = func(nodes []*Node, style string) {
+		for _, n := range nodes {
+			switch n.Kind {
+			case Text:
+				add(n.Text, style)
+			case Italic:
+				walk(n.Children, style+pick(ansi, ansiItalic))
+			case Code:
+				walk(n.Children, style+pick(ansi, ansiDim))
+			case Link:
+				add(linkText(n.Href, plain(n.Children)), style+pick(ansi, ansiUnderline))
+			}
+		}
+	}
+	walk(nodes, "")
+	if len(current) > 0 {
+		ws = append(ws, current)
+	}
+
+	return ws
+}
+
+func pick(ansi bool, code string) string {
+	if ansi {
+		return code
+	}
+
+	return ""
+}
+
+// wrap lays words out in lines of at most width runes. Words longer than
+// width get a line of their own.
+func wrap(ws []word, width int) []string {
+	var lines []string
+	var line []string
+	n := 0
+	for _, w := range ws {
+		size := w.width()
+		if len(line) > 0 && width > 0 && n+1+size > width {
+			lines = append(lines, strings.Join(line, " "))
+			line, n = nil, 0
+		}
+		if len(line) > 0 {
+			n++
+		}
+		line = append(line, w.String())
+		n += size
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+
+	return lines
+}
+
+// linkText is what a link reads as in plain text. HN shortens long URLs in
+// link labels, so a label that is a prefix of the URL gives way to the URL.
+func linkText(href, label string) string {
+	if label == "" || strings.HasPrefix(href, strings.TrimSuffix(label, "...")) {
+		return href
+	}
+
+	return label + " (" + href + ")"
+}
+
+// plain returns the text of inline nodes with white space collapsed.
+func plain(nodes []*Node) string {
+	var b strings.Builder
+	var walk func(nodes []*Node)
+	walk = func(nodes []*Node) {
+		for _, n := range nodes {
+			b.WriteString(n.Text)
+			walk(n.Children)
+		}
+	}
+	walk(nodes)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// markdownEscaper escapes what CommonMark could read as markup in text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "!", `\!`, "|", `\|`, "&", `\&`,
+)
+
+// RenderMarkdown renders doc as CommonMark. Text is escaped so that it
+// cannot turn into markup or raw HTML.
+func RenderMarkdown(doc *Node) string {
+	var blocks []string
+	for _, block := range doc.Children {
+		switch block.Kind {
+		case CodeBlock:
+			fence := strings.Repeat("`", longestRun(block.Text, '`')+1)
+			if len(fence) < 3 {
+				fence = "```"
+			}
+			blocks = append(blocks, fence+"\n"+block.Text+"\n"+fence)
+		case Quote:
+			blocks = append(blocks, "> "+escapeLineStart(markdownInline(block.Children)))
+		default:
+			blocks = append(blocks, escapeLineStart(markdownInline(block.Children)))
+		}
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+func markdownInline(nodes []*Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case Text:
+			b.WriteString(markdownEscaper.Replace(n.Text))
+		case Italic:
+			if inner := markdownInline(n.Children); strings.TrimSpace(inner) != "" {
+				b.WriteString("*" + inner + "*")
+			}
+		case Code:
+			code := plain(n.Children)
+			fence := strings.Repeat("`", longestRun(code, '`')+1)
+			b.WriteString(fence + " " + code + " " + fence)
+		case Link:
+			href := strings.NewReplacer(" ", "%20", "<", "%3C", ">", "%3E", "(", "%28", ")", "%29").Replace(n.Href)
+			label := plain(n.Children)
+			if label == "" || strings.HasPrefix(n.Href, strings.TrimSuffix(label, "...")) {
+				b.WriteString("<" + href + ">")
+			} else {
+				b.WriteString("[" + markdownInline(n.Children) + "](" + href + ")")
+			}
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// escapeLineStart escapes what would start a list item or a heading
+// underline at the beginning of a paragraph.
+func escapeLineStart(s string) string {
+	if s == "" {
+		return s
+	}
+	if strings.ContainsRune("-+=", rune(s[0])) {
+		return `\` + s
+	}
+	digits := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if digits > 0 && (s[digits] == '.' || s[digits] == ')') {
+		return s[:digits] + `\` + s[digits:]
+	}
+
+	return s
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c rune) int {
+	longest, n := 0, 0
+	for _, r := range s {
+		if r == c {
+			n++
+			if n > longest {
+				longest = n
+			}
+		} else {
+			n = 0
+		}
+	}
+
+	return longest
+}
+
+// RenderHTML renders doc as HTML made only of the tags HN uses, with every
+// text and attribute escaped. Links get rel="nofollow".
+func RenderHTML(doc *Node) string {
+	var b strings.Builder
+	for _, block := range doc.Children {
+		switch block.Kind {
+		case CodeBlock:
+			b.WriteString("<pre><code>" + html.EscapeString(block.Text) + "</code></pre>")
+		case Quote:
+			b.WriteString("<blockquote><p>")
+			htmlInline(&b, block.Children)
+			b.WriteString("</p></blockquote>")
+		default:
+			b.WriteString("<p>")
+			htmlInline(&b, block.Children)
+			b.WriteString("</p>")
+		}
+	}
+
+	return b.String()
+}
+
+// RenderInlineHTML renders doc like RenderHTML but without block tags, its
+// blocks separated by spaces, for short texts such as poll options that go
+// inside another element.
+func RenderInlineHTML(doc *Node) string {
+	var b strings.Builder
+	for i, block := range doc.Children {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		if block.Kind == CodeBlock {
+			b.WriteString("<code>" + html.EscapeString(block.Text) + "</code>")
+			continue
+		}
+		htmlInline(&b, block.Children)
+	}
+
+	return b.String()
+}
+
+func htmlInline(b *strings.Builder, nodes []*Node) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case Text:
+			b.WriteString(html.EscapeString(n.Text))
+		case Italic:
+			b.WriteString("<i>")
+			htmlInline(b, n.Children)
+			b.WriteString("</i>")
+		case Code:
+			b.WriteString("<code>")
+			htmlInline(b, n.Children)
+			b.WriteString("</code>")
+		case Link:
+			b.WriteString(`<a href="` + html.EscapeString(n.Href) + `" rel="nofollow">`)
+			htmlInline(b, n.Children)
+			b.WriteString("</a>")
+		}
+	}
+}
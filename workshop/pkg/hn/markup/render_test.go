@@ -0,0 +1,62 @@
+package markup_test
+
+import (
+	"testing"
+	"workshop-starter/pkg/hn/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const comment = `&gt; Linux users can already build this <i>trivially</i><p>` +
+	`Not my mom. See <a href="https://example.com/a/long/path?q=1&amp;r=2">https://example.com/a/...</a> ` +
+	`or <a href="https://example.com/faq">the FAQ</a>.<p>` +
+	"<pre><code>  rsync -a ~/ host:\n</code></pre><p>" +
+	"1. *Really* &lt;simple&gt;"
+
+func TestRenderText(t *testing.T) {
+	assert.Equal(t, "> Linux users can already\n"+
+		"> build this trivially\n"+
+		"\n"+
+		"Not my mom. See\n"+
+		"https://example.com/a/long/path?q=1&r=2\n"+
+		"or the FAQ\n"+
+		"(https://example.com/faq).\n"+
+		"\n"+
+		"    rsync -a ~/ host:\n"+
+		"\n"+
+		"1. *Really* <simple>", markup.RenderText(markup.Parse(comment), 26))
+
+	assert.Equal(t, "Not wrapped at all", markup.RenderText(markup.Parse("Not <i>wrapped</i> at all"), 0))
+}
+
+func TestRenderANSI(t *testing.T) {
+	assert.Equal(t, "\x1b[2m>\x1b[0m \x1b[3mquoted\x1b[0m,\n\n"+
+		"see \x1b[4mhttp://x.io\x1b[0m",
+		markup.RenderANSI(markup.Parse(`&gt; <i>quoted</i>,<p>see <a href="http://x.io">http://x.io</a>`), 80))
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	assert.Equal(t, "> Linux users can already build this *trivially*\n\n"+
+		"Not my mom. See <https://example.com/a/long/path?q=1&r=2> or [the FAQ](https://example.com/faq).\n\n"+
+		"```\n  rsync -a ~/ host:\n```\n\n"+
+		`1\. \*Really\* \<simple\>`, markup.RenderMarkdown(markup.Parse(comment)))
+
+	assert.Equal(t, "````\nuse ``` fences\n````", markup.RenderMarkdown(markup.Parse("<pre><code>use ``` fences</code></pre>")))
+	assert.Equal(t, `\- not a list`, markup.RenderMarkdown(markup.Parse("- not a list")))
+}
+
+func TestRenderHTML(t *testing.T) {
+	assert.Equal(t, "<blockquote><p>Linux users can already build this <i>trivially</i></p></blockquote>"+
+		`<p>Not my mom. See <a href="https://example.com/a/long/path?q=1&amp;r=2" rel="nofollow">https://example.com/a/...</a> `+
+		`or <a href="https://example.com/faq" rel="nofollow">the FAQ</a>.</p>`+
+		"<pre><code>  rsync -a ~/ host:</code></pre>"+
+		"<p>1. *Really* &lt;simple&gt;</p>", markup.RenderHTML(markup.Parse(comment)))
+
+	assert.Equal(t, `<p>&lt;img src=x onerror=alert(1)&gt; &#34;quoted&#34;</p>`,
+		markup.RenderHTML(markup.Parse(`&lt;img src=x onerror=alert(1)&gt; <img src=x onerror=alert(1)>"quoted"`)))
+}
+
+func TestRenderInlineHTML(t *testing.T) {
+	assert.Equal(t, `Don&#39;t <i>know</i> second`, markup.RenderInlineHTML(markup.Parse("Don&#x27;t <i>know</i><p>second")))
+	assert.Equal(t, "", markup.RenderInlineHTML(markup.Parse("")))
+}
@@ -0,0 +1,71 @@
+package hn
+
+// Poll holds the options of a poll item and the votes they received.
+type Poll struct {
+	Options []PollOption
+	Votes   int
+}
+
+// PollOption is a single answer of a poll. Options that could not be fetched
+// only carry their ID and are marked Failed.
+type PollOption struct {
+	Id      int
+	Text    string
+	Score   int
+	Percent float64
+	Failed  bool `json:",omitempty"`
+}
+
+// IsPoll reports whether the item is a poll with options to fetch.
+func (i Item) IsPoll() bool {
+	return i.Type == "poll"
+}
+
+// fetchPoll fetches every option in parts, in order, and works out their
+// share of the votes.
+func fetchPoll(get func(int) (Item, error), parts []int) (Poll, []FetchFailure) {
+	var poll Poll
+	var failures []FetchFailure
+	for _, id := range parts {
+		item, err := get(id)
+		if err != nil {
+			failures = append(failures, FetchFailure{Id: id, Err: err})
+			poll.Options = append(poll.Options, PollOption{Id: id, Failed: true})
+			continue
+		}
+
+		poll.Options = append(poll.Options, PollOption{Id: item.Id, Text: item.Text, Score: item.Score})
+		poll.Votes += item.Score
+	}
+
+	if poll.Votes > 0 {
+		for i := range poll.Options {
+			poll.Options[i].Percent = float64(poll.Options[i].Score) * 100 / float64(poll.Votes)
+		}
+	}
+
+	return poll, failures
+}
+
+// story turns a root item into a Story header, fetching the poll options
+// when the item is a poll.
+func (b *StoryBuilder) story(st *build, item Item) Story {
+	story := storyFromItem(item)
+	if !item.IsPoll() {
+		return story
+	}
+
+	poll, failures := fetchPoll(func(id int) (Item, error) {
+		return b.getItem(st.ctx, id)
+	}, item.Parts)
+	story.Poll = &poll
+	st.summary.Failures = append(st.summary.Failures, failures...)
+
+	return story
+}
+
+// FetchPoll fetches the options of a poll item with the given client. The
+// options that cannot be fetched are marked Failed and reported as failures.
+func FetchPoll(client Client, item Item) (Poll, []FetchFailure) {
+	return fetchPoll(client.GetItem, item.Parts)
+}
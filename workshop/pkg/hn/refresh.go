@@ -0,0 +1,183 @@
+package hn
+
+import "context"
+
+// StoryDiff describes what changed between two builds of the same story.
+type StoryDiff struct {
+	Title   *TextChange
+	Score   *ScoreChange
+	Added   []AddedComment
+	Removed []int
+	Deleted []int
+	Dead    []int
+	Edited  []TextChange
+}
+
+// TextChange holds the old and new text of a story title or a comment.
+type TextChange struct {
+	Id  int
+	Old string
+	New string
+}
+
+// ScoreChange holds the old and new score of a story.
+type ScoreChange struct {
+	Old int
+	New int
+}
+
+// AddedComment is a comment that appeared since the previous build, together
+// with the replies it already has.
+type AddedComment struct {
+	ParentID int
+	Comment  Comment
+}
+
+// Empty reports whether nothing changed.
+func (d StoryDiff) Empty() bool {
+	return d.Title == nil && d.Score == nil && len(d.Added) == 0 &&
+		len(d.Removed) == 0 && len(d.Deleted) == 0 && len(d.Dead) == 0 && len(d.Edited) == 0
+}
+
+// WithQuickRefresh makes Refresh skip refetching the comment tree when the
+// story's descendant count and top-level kids did not change. Edits and
+// comments turning dead or deleted then go unnoticed until the next change.
+func WithQuickRefresh() StoryOption {
+	return func(b *StoryBuilder) {
+		b.quickRefresh = true
+	}
+}
+
+// Refresh rebuilds prev, refetching existing comments to pick up edits,
+// deletions and new replies; new comments are built with the builder limits.
+// Comments that cannot be refetched are kept as they were.
+func (b *StoryBuilder) Refresh(prev Story) (Story, StoryDiff, error) {
+	story, diff, _, err := b.RefreshWithSummary(prev)
+	return story, diff, err
+}
+
+// RefreshWithSummary refreshes the story like Refresh and also reports which
+// comments were deleted, dead or could not be fetched.
+func (b *StoryBuilder) RefreshWithSummary(prev Story) (Story, StoryDiff, BuildSummary, error) {
+	st := newBuild(context.Background(), nil)
+	item, err := b.getItem(st.ctx, prev.Id)
+	if err != nil {
+		return Story{}, StoryDiff{}, st.summary, err
+	}
+
+	var diff StoryDiff
+	story := b.story(st, item)
+	story.Path = prev.Path
+	if story.Title != prev.Title {
+		diff.Title = &TextChange{Id: story.Id, Old: prev.Title, New: story.Title}
+	}
+	if story.Score != prev.Score {
+		diff.Score = &ScoreChange{Old: prev.Score, New: story.Score}
+	}
+
+	if b.quickRefresh && story.Descendants == prev.Descendants && sameIDs(item.Kids, prev.Kids) {
+		story.Comments, story.More = prev.Comments, prev.More
+		return story, diff, st.summary, nil
+	}
+
+	story.Comments, story.More = b.refreshLevel(st, &diff, story.Id, item.Kids, prev.Comments, prev.More)
+	story.Comments = b.prune(story.Comments, &st.summary)
+
+	return story, diff, st.summary, nil
+}
+
+func (b *StoryBuilder) refreshLevel(st *build, diff *StoryDiff, parentID int, kids []int, prev []Comment, prevMore []int) ([]Comment, []int) {
+	old := make(map[int]Comment, len(prev))
+	for _, c := range prev {
+		old[c.Id] = c
+	}
+	unfetched := make(map[int]bool, len(prevMore))
+	for _, id := range prevMore {
+		unfetched[id] = true
+	}
+
+	var comments []Comment
+	var more []int
+	current := make(map[int]bool, len(kids))
+	for _, k := range kids {
+		current[k] = true
+
+		if unfetched[k] {
+			more = append(more, k)
+			continue
+		}
+
+		c, known := old[k]
+		item, err := b.getItem(st.ctx, k)
+		if err != nil {
+			if known {
+				comments = append(comments, c)
+			} else {
+				c = b.placehold(Comment{Id: k, Status: StatusFailed, Err: err})
+				st.summary.record(c)
+				comments = append(comments, c)
+			}
+			continue
+		}
+
+		fresh := b.commentFromItem(item)
+		fresh.Highlighted = c.Highlighted
+		st.summary.record(fresh)
+		// A comment that failed before is seen for the first time now.
+		if !known || c.Status == StatusFailed {
+			b.fill(st, fresh.Id, &fresh.ChildComments, &fresh.More, item.Kids)
+			// Pruned comments are not kept, so they come back on every refresh.
+			if fresh.Status != StatusDeleted && len(b.prune([]Comment{fresh}, &BuildSummary{})) > 0 {
+				diff.Added = append(diff.Added, AddedComment{ParentID: parentID, Comment: fresh})
+			}
+			comments = append(comments, fresh)
+			continue
+		}
+
+		if c.Status != StatusDeleted && fresh.Status == StatusDeleted {
+			diff.Deleted = append(diff.Deleted, k)
+		} else if c.Status == StatusOK && fresh.Status == StatusDead {
+			diff.Dead = append(diff.Dead, k)
+		} else if c.Status == StatusOK && fresh.Status == StatusOK && c.Text != fresh.Text {
+			diff.Edited = append(diff.Edited, TextChange{Id: k, Old: c.Text, New: fresh.Text})
+		}
+		fresh.ChildComments, fresh.More = b.refreshLevel(st, diff, k, item.Kids, c.ChildComments, c.More)
+		comments = append(comments, fresh)
+	}
+
+	for _, id := range knownIDs(prev, prevMore) {
+		if !current[id] {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	return comments, more
+}
+
+func knownIDs(comments []Comment, more []int) []int {
+	ids := make([]int, 0, len(comments)+len(more))
+	for _, c := range comments {
+		ids = append(ids, c.Id)
+	}
+
+	return append(ids, more...)
+}
+
+// sameIDs compares two ID lists ignoring order.
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[int]int, len(a))
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+
+	return true
+}
@@ -0,0 +1,256 @@
+package hn_test
+
+import (
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveItems(client *mock.MockClient, items map[int]hn.Item) {
+	client.EXPECT().GetItem(gomock.Any()).DoAndReturn(func(id int) (hn.Item, error) {
+		item, ok := items[id]
+		if !ok {
+			return hn.Item{}, hn.ErrItemNotFound
+		}
+		return item, nil
+	}).AnyTimes()
+}
+
+func buildThreadStory(t *testing.T) hn.Story {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	items := threadItems()
+	root := items[100]
+	root.Descendants = 6
+	items[100] = root
+
+	client := mock.NewMockClient(ctrl)
+	serveItems(client, items)
+
+	story, err := hn.NewStoryBuilder(client).Build(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return story
+}
+
+func TestStoryBuilder_Refresh(t *testing.T) {
+	t.Run("unchanged thread is not refetched", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prev := buildThreadStory(t)
+		root := threadItems()[100]
+		root.Descendants = 6
+		root.Score = 12
+		root.Title = "Ask HN: Threads, again?"
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(100).Return(root, nil)
+
+		story, diff, err := hn.NewStoryBuilder(client, hn.WithQuickRefresh()).Refresh(prev)
+		assert.NoError(t, err)
+
+		assert.Equal(t, prev.Comments, story.Comments)
+		assert.Equal(t, &hn.TextChange{Id: 100, Old: "Ask HN: Threads?", New: "Ask HN: Threads, again?"}, diff.Title)
+		assert.Equal(t, &hn.ScoreChange{Old: 0, New: 12}, diff.Score)
+		assert.Empty(t, diff.Added)
+	})
+
+	t.Run("nothing changed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prev := buildThreadStory(t)
+		items := threadItems()
+		root := items[100]
+		root.Descendants = 6
+		items[100] = root
+
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+
+		story, diff, summary, err := hn.NewStoryBuilder(client).RefreshWithSummary(prev)
+		assert.NoError(t, err)
+		assert.True(t, diff.Empty())
+		assert.Equal(t, prev.Comments, story.Comments)
+		assert.Equal(t, 6, summary.Fetched)
+	})
+
+	t.Run("comments that turn dead", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prev := buildThreadStory(t)
+		items := threadItems()
+		root := items[100]
+		root.Descendants = 6
+		items[100] = root
+		c104 := items[104]
+		c104.Dead = true
+		items[104] = c104
+
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+
+		story, diff, summary, err := hn.NewStoryBuilder(client).RefreshWithSummary(prev)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{104}, diff.Dead)
+		assert.Equal(t, hn.StatusDead, story.Comments[0].ChildComments[1].Status)
+		assert.Equal(t, 1, summary.Dead)
+	})
+
+	t.Run("pruned comments", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		items := threadItems()
+		c104 := items[104]
+		c104.Dead = true
+		items[104] = c104
+		root := items[100]
+		root.Kids = []int{101, 102, 109}
+		root.Descendants = 6
+		items[100] = root
+		items[109] = hn.Item{Id: 109, Type: "comment", Parent: 100, Deleted: true}
+
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+		prev, err := hn.NewStoryBuilder(client, hn.WithPruneDead(), hn.WithPruneDeleted()).Build(100)
+		assert.NoError(t, err)
+		assert.Len(t, prev.Comments, 2)
+
+		_, diff, err := hn.NewStoryBuilder(client, hn.WithPruneDead(), hn.WithPruneDeleted()).Refresh(prev)
+		assert.NoError(t, err)
+		assert.True(t, diff.Empty())
+
+		quick := mock.NewMockClient(ctrl)
+		quick.EXPECT().GetItem(100).Return(root, nil)
+		_, diff, err = hn.NewStoryBuilder(quick, hn.WithPruneDead(), hn.WithPruneDeleted(), hn.WithQuickRefresh()).Refresh(prev)
+		assert.NoError(t, err)
+		assert.True(t, diff.Empty())
+	})
+
+	t.Run("new, edited, deleted and removed comments", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prev := buildThreadStory(t)
+
+		items := threadItems()
+		root := items[100]
+		root.Descendants = 7
+		items[100] = root
+		c101 := items[101]
+		c101.Kids = []int{103}
+		items[101] = c101
+		c104 := items[104]
+		delete(items, 104)
+		c105 := items[105]
+		c105.Text = "105, edited"
+		c105.Kids = []int{107}
+		items[105] = c105
+		items[106] = hn.Item{Id: 106, Type: "comment", Parent: 103, Deleted: true}
+		items[107] = hn.Item{Id: 107, Type: "comment", Author: "g", Text: "107", Parent: 105, Kids: []int{108}}
+		items[108] = hn.Item{Id: 108, Type: "comment", Author: "h", Text: "108", Parent: 107}
+
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+
+		story, diff, err := hn.NewStoryBuilder(client).Refresh(prev)
+		assert.NoError(t, err)
+
+		assert.Nil(t, diff.Title)
+		assert.Nil(t, diff.Score)
+		assert.Equal(t, []int{c104.Id}, diff.Removed)
+		assert.Equal(t, []int{106}, diff.Deleted)
+		assert.Equal(t, []hn.TextChange{{Id: 105, Old: "105", New: "105, edited"}}, diff.Edited)
+		assert.Len(t, diff.Added, 1)
+		assert.Equal(t, 105, diff.Added[0].ParentID)
+		assert.Equal(t, 107, diff.Added[0].Comment.Id)
+		assert.Equal(t, 108, diff.Added[0].Comment.ChildComments[0].Id)
+
+		assert.Equal(t, 7, story.Descendants)
+		assert.Len(t, story.Comments[0].ChildComments, 1)
+		assert.Equal(t, hn.StatusDeleted, story.Comments[0].ChildComments[0].ChildComments[0].Status)
+		assert.Equal(t, 107, story.Comments[1].ChildComments[0].ChildComments[0].Id)
+	})
+
+	t.Run("unreachable comments are kept", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prev := buildThreadStory(t)
+
+		items := threadItems()
+		root := items[100]
+		root.Descendants = 7
+		items[100] = root
+		delete(items, 102)
+
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+
+		story, diff, err := hn.NewStoryBuilder(client).Refresh(prev)
+		assert.NoError(t, err)
+
+		assert.Empty(t, diff.Removed)
+		assert.Equal(t, prev.Comments[1], story.Comments[1])
+	})
+
+	t.Run("removed comments that were not fetched", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		items := threadItems()
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+		builder := hn.NewStoryBuilder(client, hn.WithMaxChildren(1))
+		prev, err := builder.Build(100)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{102}, prev.More)
+
+		root := items[100]
+		root.Kids = []int{101}
+		root.Descendants = 3
+		items[100] = root
+
+		_, diff, err := builder.Refresh(prev)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{102}, diff.Removed)
+	})
+
+	t.Run("failed comments that come back are added", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		items := threadItems()
+		c102 := items[102]
+		delete(items, 102)
+		client := mock.NewMockClient(ctrl)
+		serveItems(client, items)
+		prev, err := hn.NewStoryBuilder(client).Build(100)
+		assert.NoError(t, err)
+		assert.Equal(t, hn.StatusFailed, prev.Comments[1].Status)
+
+		items[102] = c102
+		root := items[100]
+		root.Descendants = 6
+		items[100] = root
+
+		story, diff, err := hn.NewStoryBuilder(client).Refresh(prev)
+		assert.NoError(t, err)
+		assert.Len(t, diff.Added, 1)
+		assert.Equal(t, 100, diff.Added[0].ParentID)
+		assert.Equal(t, 102, diff.Added[0].Comment.Id)
+		assert.Equal(t, 105, diff.Added[0].Comment.ChildComments[0].Id)
+		assert.Empty(t, diff.Edited)
+		assert.Equal(t, "102", story.Comments[1].Text)
+	})
+}
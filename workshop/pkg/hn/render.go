@@ -0,0 +1,211 @@
+package hn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"workshop-starter/pkg/hn/markup"
+)
+
+// defaultRenderWidth is the terminal width TextRenderer wraps at when none
+// is set.
+const defaultRenderWidth = 80
+
+// minTextWidth keeps deeply nested comments readable on narrow terminals.
+const minTextWidth = 20
+
+// depthColors are the ANSI colours of the indentation guides and authors,
+// cycling with depth.
+var depthColors = []string{"\x1b[36m", "\x1b[32m", "\x1b[33m", "\x1b[35m", "\x1b[34m", "\x1b[31m"}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+)
+
+// TextRenderer prints a Story and its comment tree for a terminal. The zero
+// value wraps at 80 columns without colour.
+type TextRenderer struct {
+	// Width is the column text is wrapped at.
+	Width int
+	// Color enables ANSI colours, one per depth.
+	Color bool
+	// Now is used for the age of stories and comments; time.Now when nil.
+	Now func() time.Time
+}
+
+// Render writes story to w: a header with the title, URL and points, then
+// every comment with an author and age line and its wrapped text.
+func (r TextRenderer) Render(w io.Writer, story Story) error {
+	p := &printer{w: w, r: r, now: time.Now()}
+	if r.Now != nil {
+		p.now = r.Now()
+	}
+
+	// Titles, URLs and names are plain text, but may still hold control
+	// characters that would drive the terminal.
+	p.line(p.paint(ansiBold, markup.Clean(story.Title)))
+	if story.Url != "" {
+		p.line(markup.Clean(story.Url))
+	}
+	byline := fmt.Sprintf("%d points by %s", story.Score, markup.Clean(story.Author))
+	if age := p.age(story.Time); age != "" {
+		byline += " " + age
+	}
+	p.line(fmt.Sprintf("%s | %s", byline, Plural(story.Descendants, "comment", "comments")))
+	if story.Text != "" {
+		p.line("")
+		for _, l := range lines(p.text(story.Text, 0)) {
+			p.line(l)
+		}
+	}
+	if story.Poll != nil {
+		for _, o := range story.Poll.Options {
+			if o.Failed {
+				p.line(fmt.Sprintf("  <failed %d>", o.Id))
+				continue
+			}
+			p.line(fmt.Sprintf("  %s (%d points, %.1f%%)", InlineText(o.Text), o.Score, o.Percent))
+		}
+	}
+	for _, c := range story.Comments {
+		p.line("")
+		p.comment(c, 1)
+	}
+	if len(story.More) > 0 {
+		p.line("")
+		p.more(len(story.More), 1)
+	}
+
+	return p.err
+}
+
+// printer holds the state of one Render call. Write errors are kept so that
+// the layout code does not have to check each line.
+type printer struct {
+	w   io.Writer
+	r   TextRenderer
+	now time.Time
+	err error
+}
+
+func (p *printer) line(s string) {
+	if p.err == nil {
+		_, p.err = io.WriteString(p.w, strings.TrimRight(s, " ")+"\n")
+	}
+}
+
+func (p *printer) paint(code, s string) string {
+	if !p.r.Color || s == "" {
+		return s
+	}
+
+	return code + s + ansiReset
+}
+
+func (p *printer) color(depth int) string {
+	return depthColors[(depth-1)%len(depthColors)]
+}
+
+// guides returns the indentation guides of a comment at depth.
+func (p *printer) guides(depth int) string {
+	var b strings.Builder
+	for d := 1; d <= depth; d++ {
+		b.WriteString(p.paint(p.color(d), "│"))
+		b.WriteString(" ")
+	}
+
+	return b.String()
+}
+
+func (p *printer) age(t int64) string {
+	if t == 0 {
+		return ""
+	}
+
+	return ago(p.now, time.Unix(t, 0))
+}
+
+// text wraps HN markup to the width left at depth.
+func (p *printer) text(text string, depth int) string {
+	width := p.r.Width
+	if width <= 0 {
+		width = defaultRenderWidth
+	}
+	width -= 2 * depth
+	if width < minTextWidth {
+		width = minTextWidth
+	}
+	if p.r.Color {
+		return markup.RenderANSI(markup.Parse(text), width)
+	}
+
+	return markup.RenderText(markup.Parse(text), width)
+}
+
+func (p *printer) comment(c Comment, depth int) {
+	prefix := p.guides(depth)
+	if c.Status != StatusOK && c.Author == "" {
+		text := c.Text
+		if text == "" {
+			text = DefaultPlaceholder(c)
+		}
+		p.line(prefix + p.paint(ansiDim, markup.Clean(text)))
+	} else {
+		header := p.paint(ansiBold+p.color(depth), markup.Clean(c.Author))
+		if age := p.age(c.Time); age != "" {
+			header += " " + p.paint(ansiDim, age)
+		}
+		p.line(prefix + header)
+
+		for _, l := range lines(p.text(c.Text, depth)) {
+			p.line(prefix + l)
+		}
+	}
+
+	for _, child := range c.ChildComments {
+		p.line(p.guides(depth))
+		p.comment(child, depth+1)
+	}
+	if len(c.More) > 0 {
+		p.line(p.guides(depth))
+		p.more(len(c.More), depth+1)
+	}
+}
+
+func (p *printer) more(n, depth int) {
+	p.line(p.guides(depth) + p.paint(ansiDim, "["+Plural(n, "more reply", "more replies")+"]"))
+}
+
+// Plural formats a count with the singular or plural noun, as in
+// "1 comment" and "3 comments".
+func Plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// PlainText converts HN comment HTML to plain text lines wrapped at width,
+// with a blank line between paragraphs. Code blocks are indented by two
+// spaces and never wrapped.
+func PlainText(text string, width int) []string {
+	return lines(markup.RenderText(markup.Parse(text), width))
+}
+
+// InlineText converts short HN markup, like a poll option, to a single line
+// of plain text.
+func InlineText(text string) string {
+	return strings.Join(strings.Fields(markup.RenderText(markup.Parse(text), 0)), " ")
+}
+
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
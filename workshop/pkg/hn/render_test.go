@@ -0,0 +1,117 @@
+package hn_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func renderStory() hn.Story {
+	return hn.Story{
+		Id:          8863,
+		Author:      "dhouston",
+		Title:       "My YC app: Dropbox",
+		Url:         "http://www.getdropbox.com/u/2/screencast.html",
+		Score:       104,
+		Descendants: 4,
+		Time:        1175714200,
+		Comments: []hn.Comment{
+			{
+				Id:     9224,
+				Author: "BrandonM",
+				Time:   1175714200 + 3600,
+				Text:   "I have a few qualms with this app:<p>1. For a Linux user, you can already build such a system yourself quite trivially &amp; easily.",
+				ChildComments: []hn.Comment{
+					{
+						Id:     9479,
+						Author: "dhouston",
+						Time:   1175714200 + 7200,
+						Text:   `See <a href="http://example.com/a/very/long/path">http://example.com/a/very/...</a> and:<p><pre><code>  rsync -a ~/ host:` + "\n</code></pre>",
+						More:   []int{9480},
+					},
+				},
+			},
+			{Id: 8917, Status: hn.StatusDeleted},
+		},
+	}
+}
+
+func TestTextRenderer(t *testing.T) {
+	now := func() time.Time { return time.Unix(1175714200+3*3600, 0) }
+
+	t.Run("plain", func(t *testing.T) {
+		var b bytes.Buffer
+		err := hn.TextRenderer{Width: 40, Now: now}.Render(&b, renderStory())
+		assert.NoError(t, err)
+		assert.Equal(t, `My YC app: Dropbox
+http://www.getdropbox.com/u/2/screencast.html
+104 points by dhouston 3 hours ago | 4 comments
+
+│ BrandonM 2 hours ago
+│ I have a few qualms with this app:
+│
+│ 1. For a Linux user, you can already
+│ build such a system yourself quite
+│ trivially & easily.
+│
+│ │ dhouston 1 hour ago
+│ │ See
+│ │ http://example.com/a/very/long/path
+│ │ and:
+│ │
+│ │     rsync -a ~/ host:
+│ │
+│ │ │ [1 more reply]
+
+│ [deleted]
+`, b.String())
+	})
+
+	t.Run("colour", func(t *testing.T) {
+		story := hn.Story{Title: "Ask HN", Author: "pg", Comments: []hn.Comment{{Author: "sama", Text: "Hi"}}}
+
+		var b bytes.Buffer
+		err := hn.TextRenderer{Color: true, Now: now}.Render(&b, story)
+		assert.NoError(t, err)
+		assert.Equal(t, "\x1b[1mAsk HN\x1b[0m\n0 points by pg | 0 comments\n\n"+
+			"\x1b[36m│\x1b[0m \x1b[1m\x1b[36msama\x1b[0m\n"+
+			"\x1b[36m│\x1b[0m Hi\n", b.String())
+	})
+
+	t.Run("control characters and poll options", func(t *testing.T) {
+		story := hn.Story{
+			Title:    "Ask HN\x1b[2J",
+			Author:   "pg\x1b]0;owned\x07",
+			Poll:     &hn.Poll{Options: []hn.PollOption{{Text: "Don&#x27;t <i>know</i>\x1b[31m", Score: 3, Percent: 60}}},
+			Comments: []hn.Comment{{Author: "sama\x1b[1m", Text: "Hi"}},
+		}
+
+		var b bytes.Buffer
+		err := hn.TextRenderer{Now: now}.Render(&b, story)
+		assert.NoError(t, err)
+		assert.Equal(t, "Ask HN[2J\n0 points by pg]0;owned | 0 comments\n  Don't know[31m (3 points, 60.0%)\n\n"+
+			"│ sama[1m\n│ Hi\n", b.String())
+	})
+
+	t.Run("story text", func(t *testing.T) {
+		story := hn.Story{Title: "Ask HN: The Arc Effect", Author: "pg", Score: 25, Text: "Is it <i>really</i> worth it?<p>Asking for a friend."}
+
+		var b bytes.Buffer
+		err := hn.TextRenderer{Now: now}.Render(&b, story)
+		assert.NoError(t, err)
+		assert.Equal(t, "Ask HN: The Arc Effect\n25 points by pg | 0 comments\n\nIs it really worth it?\n\nAsking for a friend.\n", b.String())
+	})
+
+	t.Run("writer error", func(t *testing.T) {
+		err := hn.TextRenderer{}.Render(errDump{}, renderStory())
+		assert.Error(t, err)
+	})
+}
+
+func TestPlainText(t *testing.T) {
+	lines := hn.PlainText("Fish &amp; chips<p>are <i>great</i> food", 10)
+	assert.Equal(t, []string{"Fish &", "chips", "", "are great", "food"}, lines)
+}
@@ -0,0 +1,110 @@
+package hn
+
+import (
+	"fmt"
+	"io"
+)
+
+// Sink is an extra output of a Dump. Every sink is fed from the same fetch
+// pass as the writer given to Dump.
+type Sink struct {
+	// Name identifies the sink in logs and reports.
+	Name string
+	// W is where the sink writes; Run fails without one.
+	W io.Writer
+	// Encoder is the format of the sink, NewLegacyEncoder when nil.
+	Encoder Encoder
+	// Filter, when set, narrows the items the sink gets further than the
+	// filter of the Dump.
+	Filter *Filter
+}
+
+// SinkReport tells what a Dump run wrote to one Sink.
+type SinkReport struct {
+	Name    string
+	Written int
+	// Err is the failure that made the sink stop, if any.
+	Err error
+}
+
+// WithSinks makes Dump write to the given sinks besides its writer. A sink
+// that fails is dropped and the others go on; Dump then returns the first
+// failure once it is done. Sinks cannot be combined with checkpoints.
+func WithSinks(sinks ...Sink) DumpOption {
+	return func(d *Dump) {
+		d.sinks = append(d.sinks, sinks...)
+	}
+}
+
+// WithStrictSinks makes Dump stop as soon as any of its outputs fails.
+func WithStrictSinks() DumpOption {
+	return func(d *Dump) {
+		d.strictSinks = true
+	}
+}
+
+// output is the running state of the Dump writer or of a Sink.
+type output struct {
+	name    string
+	primary bool
+	w       io.Writer
+	encoder Encoder
+	filter  *Filter
+	top     *topK
+	written int
+	err     error
+}
+
+func (d *Dump) outputs(w io.Writer) []*output {
+	outs := []*output{{name: "output", primary: true, w: w, encoder: d.encoder}}
+	for _, s := range d.sinks {
+		encoder := s.Encoder
+		if encoder == nil {
+			encoder = NewLegacyEncoder()
+		}
+		outs = append(outs, &output{name: s.Name, w: s.W, encoder: encoder, filter: s.Filter})
+	}
+	if d.topK > 0 {
+		now := d.now()
+		for _, o := range outs {
+			o.top = newTopK(d.topK, d.sortKey, now)
+		}
+	}
+
+	return outs
+}
+
+// fail records err as the reason o stopped. It returns the error the run
+// has to stop with: err itself when sinks are strict or nothing is left to
+// write to, nil otherwise.
+func (d *Dump) fail(outs []*output, o *output, err error) error {
+	o.err = err
+	if len(outs) > 1 {
+		d.logger.Printf("dump: dropping sink %s: %v", o.name, err)
+	}
+	if d.strictSinks || !alive(outs) {
+		return o.wrap()
+	}
+
+	return nil
+}
+
+func alive(outs []*output) bool {
+	for _, o := range outs {
+		if o.err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wrap returns the failure of o, naming the sink unless it is the Dump
+// writer.
+func (o *output) wrap() error {
+	if o.err == nil || o.primary {
+		return o.err
+	}
+
+	return fmt.Errorf("dump: sink %s: %w", o.name, o.err)
+}
@@ -0,0 +1,142 @@
+package hn_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumper_Sinks(t *testing.T) {
+	t.Run("one pass, several outputs", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+		client.EXPECT().GetItem(3).Return(getItem(3), nil)
+
+		var legacy, csv, jsonl bytes.Buffer
+		report, err := hn.NewDump(client, 3, hn.WithSinks(
+			hn.Sink{Name: "csv", W: &csv, Encoder: hn.NewCSVEncoder(hn.ColumnTitle, hn.ColumnScore)},
+			hn.Sink{Name: "jsonl", W: &jsonl, Encoder: hn.NewJSONLinesEncoder(hn.ColumnScore), Filter: &hn.Filter{MinScore: 4}},
+		)).Run(&legacy)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Title 5,5\nTitle 4,4\nTitle 3,3\n", legacy.String())
+		assert.Equal(t, "title,score\r\nTitle 5,5\r\nTitle 4,4\r\nTitle 3,3\r\n", csv.String())
+		assert.Equal(t, "{\"score\":5}\n{\"score\":4}\n", jsonl.String())
+		assert.Equal(t, 3, report.Written)
+		assert.Equal(t, []hn.SinkReport{{Name: "csv", Written: 3}, {Name: "jsonl", Written: 2}}, report.Sinks)
+	})
+
+	t.Run("a failing sink is dropped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+
+		var b bytes.Buffer
+		report, err := hn.NewDump(client, 2, hn.WithSinks(hn.Sink{Name: "broken", W: errDump{}, Encoder: hn.NewLegacyEncoder()})).Run(&b)
+		assert.EqualError(t, err, "dump: sink broken: Cannot dump items")
+		assert.Equal(t, "Title 5,5\nTitle 4,4\n", b.String())
+		assert.Equal(t, 2, report.Written)
+		assert.Len(t, report.Sinks, 1)
+		assert.Error(t, report.Sinks[0].Err)
+	})
+
+	t.Run("sinks outlive the writer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDump(client, 2, hn.WithSinks(hn.Sink{Name: "copy", W: &b, Encoder: hn.NewLegacyEncoder()})).Dump(errDump{})
+		assert.EqualError(t, err, "Cannot dump items")
+		assert.Equal(t, "Title 5,5\nTitle 4,4\n", b.String())
+	})
+
+	t.Run("strict sinks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+
+		var b bytes.Buffer
+		dump := hn.NewDump(client, 2, hn.WithStrictSinks(), hn.WithSinks(hn.Sink{Name: "broken", W: errDump{}, Encoder: hn.NewLegacyEncoder()}))
+		err := dump.Dump(&b)
+		assert.EqualError(t, err, "dump: sink broken: Cannot dump items")
+		assert.Equal(t, "Title 5,5\n", b.String())
+	})
+
+	t.Run("top-K per sink", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		for id := 5; id >= 1; id-- {
+			item := getItem(id)
+			if id%2 == 0 {
+				item.Type = "job"
+			}
+			client.EXPECT().GetItem(id).Return(item, nil)
+		}
+
+		var all, jobs bytes.Buffer
+		dump := hn.NewDump(client, 5, hn.WithTop(2, hn.SortByScore), hn.WithSinks(
+			hn.Sink{Name: "jobs", W: &jobs, Encoder: hn.NewLegacyEncoder(), Filter: &hn.Filter{Types: []string{"job"}}},
+		))
+		assert.NoError(t, dump.Dump(&all))
+		assert.Equal(t, "Title 5,5\nTitle 4,4\n", all.String())
+		assert.Equal(t, "Title 4,4\nTitle 2,2\n", jobs.String())
+	})
+
+	t.Run("no checkpoints", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		checkpoint := filepath.Join(t.TempDir(), "dump.checkpoint")
+		client := mock.NewMockClient(ctrl)
+		dump := hn.NewDump(client, 4, hn.WithCheckpoint(checkpoint, 1), hn.WithSinks(hn.Sink{Name: "copy", W: &bytes.Buffer{}, Encoder: hn.NewLegacyEncoder()}))
+		_, err := dump.Run(&bytes.Buffer{})
+		assert.Error(t, err)
+	})
+	t.Run("legacy encoder by default", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+
+		var out, sink bytes.Buffer
+		assert.NoError(t, hn.NewDump(client, 1, hn.WithSinks(hn.Sink{Name: "copy", W: &sink})).Dump(&out))
+		assert.Equal(t, "Title 5,5\n", sink.String())
+	})
+
+	t.Run("sink without a writer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		dump := hn.NewDump(client, 1, hn.WithSinks(hn.Sink{Name: "copy", Encoder: hn.NewLegacyEncoder()}))
+		_, err := dump.Run(&bytes.Buffer{})
+		assert.EqualError(t, err, "dump: sink copy has no writer")
+	})
+}
@@ -0,0 +1,87 @@
+package hn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IDSource yields the item IDs a Dump looks at, in order.
+type IDSource interface {
+	// Next returns the next ID, or false once the source is exhausted.
+	Next() (int, bool, error)
+}
+
+type idRange struct {
+	next, to, step int
+}
+
+// IDRange yields every ID from from to to, both inclusive. The range is
+// descending when from is greater than to.
+func IDRange(from, to int) IDSource {
+	step := 1
+	if from > to {
+		step = -1
+	}
+
+	return &idRange{next: from, to: to, step: step}
+}
+
+func (r *idRange) Next() (int, bool, error) {
+	if r.step > 0 && r.next > r.to || r.step < 0 && r.next < r.to {
+		return 0, false, nil
+	}
+	id := r.next
+	r.next += r.step
+
+	return id, true, nil
+}
+
+type idList struct {
+	ids []int
+}
+
+// IDList yields the given IDs in order.
+func IDList(ids ...int) IDSource {
+	return &idList{ids: ids}
+}
+
+func (l *idList) Next() (int, bool, error) {
+	if len(l.ids) == 0 {
+		return 0, false, nil
+	}
+	id := l.ids[0]
+	l.ids = l.ids[1:]
+
+	return id, true, nil
+}
+
+type idReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// IDReader reads one ID per line from r. Blank lines and lines starting
+// with # are ignored.
+func IDReader(r io.Reader) IDSource {
+	return &idReader{scanner: bufio.NewScanner(r)}
+}
+
+func (r *idReader) Next() (int, bool, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, err := strconv.Atoi(text)
+		if err != nil {
+			return 0, false, fmt.Errorf("line %d: invalid item ID %q", r.line, text)
+		}
+		return id, true, nil
+	}
+
+	return 0, false, r.scanner.Err()
+}
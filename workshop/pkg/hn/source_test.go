@@ -0,0 +1,138 @@
+package hn_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func drain(t *testing.T, source hn.IDSource) []int {
+	t.Helper()
+	var ids []int
+	for {
+		id, ok, err := source.Next()
+		assert.NoError(t, err)
+		if !ok {
+			return ids
+		}
+		ids = append(ids, id)
+	}
+}
+
+func TestIDSources(t *testing.T) {
+	t.Run("ascending range", func(t *testing.T) {
+		assert.Equal(t, []int{3, 4, 5}, drain(t, hn.IDRange(3, 5)))
+	})
+
+	t.Run("descending range", func(t *testing.T) {
+		assert.Equal(t, []int{5, 4, 3}, drain(t, hn.IDRange(5, 3)))
+	})
+
+	t.Run("single ID range", func(t *testing.T) {
+		assert.Equal(t, []int{7}, drain(t, hn.IDRange(7, 7)))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		assert.Equal(t, []int{8863, 1, 42}, drain(t, hn.IDList(8863, 1, 42)))
+		assert.Empty(t, drain(t, hn.IDList()))
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		r := strings.NewReader("# front page\n8863\n\n  42 \n1\n")
+		assert.Equal(t, []int{8863, 42, 1}, drain(t, hn.IDReader(r)))
+	})
+
+	t.Run("invalid reader line", func(t *testing.T) {
+		source := hn.IDReader(strings.NewReader("8863\nfoo\n"))
+
+		id, ok, err := source.Next()
+		assert.Equal(t, 8863, id)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+
+		_, _, err = source.Next()
+		assert.EqualError(t, err, `line 2: invalid item ID "foo"`)
+	})
+}
+
+func TestDumper_Sources(t *testing.T) {
+	t.Run("range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(2).Return(getItem(2), nil)
+		client.EXPECT().GetItem(3).Return(getItem(3), nil)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDumpFrom(client, hn.IDRange(2, 4)).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Title 2,2\nTitle 3,3\nTitle 4,4\n", b.String())
+	})
+
+	t.Run("list with limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(1).Return(getItem(1), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDumpFrom(client, hn.IDList(5, 1, 3), hn.WithLimit(2)).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "Title 5,5\nTitle 1,1\n", b.String())
+	})
+
+	t.Run("reader error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+
+		var b bytes.Buffer
+		err := hn.NewDumpFrom(client, hn.IDReader(strings.NewReader("5\nfive\n"))).Dump(&b)
+		assert.Error(t, err)
+		assert.Equal(t, "Title 5,5\n", b.String())
+	})
+
+	t.Run("resume a list", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dir := t.TempDir()
+		checkpoint := filepath.Join(dir, "dump.checkpoint")
+		output, err := os.Create(filepath.Join(dir, "dump.txt"))
+		assert.NoError(t, err)
+		defer output.Close()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(1).Return(getItem(1), nil)
+		client.EXPECT().GetItem(3).Return(hn.Item{}, fmt.Errorf("timeout"))
+
+		_, err = hn.NewDumpFrom(client, hn.IDList(1, 3, 5), hn.WithCheckpoint(checkpoint, 1), hn.WithFailFast()).Run(output)
+		assert.Error(t, err)
+
+		client.EXPECT().GetItem(3).Return(getItem(3), nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+
+		_, err = hn.NewDumpFrom(client, hn.IDList(1, 3, 5), hn.WithCheckpoint(checkpoint, 1)).Run(output)
+		assert.NoError(t, err)
+
+		assert.NoError(t, output.Sync())
+		blob, err := ioutil.ReadFile(output.Name())
+		assert.NoError(t, err)
+		assert.Equal(t, "Title 1,1\nTitle 3,3\nTitle 5,5\n", string(blob))
+	})
+}
@@ -1,53 +1,455 @@
 package hn
 
+import (
+	"context"
+	"fmt"
+)
+
 type Story struct {
-	Id       int
-	Author   string `json:"by"`
-	Title    string
+	Id          int
+	Author      string `json:"by"`
+	Title       string
+	Url         string
+	Score       int
+	Descendants int
+	// Time is when the story was posted, in Unix seconds.
+	Time int64 `json:",omitempty"`
+	// Text is the body of Ask HN posts and polls, in HN markup.
+	Text     string `json:",omitempty"`
 	Comments []Comment
+	// More lists top-level comment IDs that were not fetched because of a
+	// build limit. They can be loaded later with StoryBuilder.Expand.
+	More []int `json:",omitempty"`
+	// Poll is set for poll items.
+	Poll *Poll `json:",omitempty"`
+	// Path holds the comment IDs from the top level down to the comment a
+	// story was built from with StoryBuilder.BuildFrom.
+	Path []int `json:",omitempty"`
+	// Kids holds the top-level comment IDs as of the build, the pruned ones
+	// included. Refresh compares them with the current ones.
+	Kids []int `json:"-"`
 }
 
 type Comment struct {
-	Id            int
-	Author        string `json:"by"`
-	Text          string
+	Id     int
+	Author string `json:"by"`
+	Text   string
+	// Time is when the comment was posted, in Unix seconds.
+	Time          int64 `json:",omitempty"`
 	ChildComments []Comment
+	// More lists reply IDs that were not fetched because of a build limit.
+	More   []int         `json:",omitempty"`
+	Status CommentStatus `json:",omitempty"`
+	// Highlighted marks the comments on Story.Path.
+	Highlighted bool `json:",omitempty"`
+	// Err holds the GetItem error for comments with StatusFailed.
+	Err error `json:"-"`
+}
+
+// CommentStatus tells whether a comment could be fetched and is visible.
+type CommentStatus int
+
+const (
+	StatusOK CommentStatus = iota
+	StatusDeleted
+	StatusDead
+	StatusFailed
+)
+
+func (s CommentStatus) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusDeleted:
+		return "deleted"
+	case StatusDead:
+		return "dead"
+	case StatusFailed:
+		return "failed"
+	}
+
+	return fmt.Sprintf("CommentStatus(%d)", int(s))
+}
+
+// DefaultPlaceholder renders the text HN itself shows in place of comments
+// that are not visible.
+func DefaultPlaceholder(c Comment) string {
+	switch c.Status {
+	case StatusDeleted:
+		return "[deleted]"
+	case StatusDead:
+		return "[dead]"
+	case StatusFailed:
+		return "[[Comment not found]]"
+	}
+
+	return c.Text
+}
+
+// BuildSummary describes what happened while fetching a comment tree.
+type BuildSummary struct {
+	Fetched  int
+	Deleted  int
+	Dead     int
+	Pruned   int
+	Failures []FetchFailure
+}
+
+// FetchFailure records a comment that could not be fetched.
+type FetchFailure struct {
+	Id  int
+	Err error
+}
+
+func (s *BuildSummary) record(c Comment) {
+	switch c.Status {
+	case StatusDeleted:
+		s.Deleted++
+	case StatusDead:
+		s.Dead++
+	case StatusFailed:
+		s.Failures = append(s.Failures, FetchFailure{Id: c.Id, Err: c.Err})
+		return
+	}
+	s.Fetched++
+}
+
+// Truncated reports whether some top-level comments were left unfetched.
+func (s Story) Truncated() bool {
+	return len(s.More) > 0
+}
+
+// Truncated reports whether some replies were left unfetched.
+func (c Comment) Truncated() bool {
+	return len(c.More) > 0
+}
+
+// StoryOption configures a StoryBuilder.
+type StoryOption func(*StoryBuilder)
+
+// WithMaxDepth limits how deep the comment tree is built. Top-level comments
+// are at depth 1. Zero means unlimited.
+func WithMaxDepth(depth int) StoryOption {
+	return func(b *StoryBuilder) {
+		b.maxDepth = depth
+	}
+}
+
+// WithMaxComments limits the total number of comments fetched by a single
+// Build or Expand call. Zero means unlimited.
+func WithMaxComments(n int) StoryOption {
+	return func(b *StoryBuilder) {
+		b.maxComments = n
+	}
+}
+
+// WithMaxChildren limits the number of replies fetched for any single node.
+// Zero means unlimited.
+func WithMaxChildren(n int) StoryOption {
+	return func(b *StoryBuilder) {
+		b.maxChildren = n
+	}
+}
+
+// WithPlaceholder sets the text used for comments that are deleted, dead or
+// failed to fetch. The default is DefaultPlaceholder, which mimics the HN
+// site; nil leaves the text as returned by the API.
+func WithPlaceholder(placeholder func(Comment) string) StoryOption {
+	return func(b *StoryBuilder) {
+		b.placeholder = placeholder
+	}
+}
+
+// WithPruneDeleted drops deleted comments that have no replies left.
+func WithPruneDeleted() StoryOption {
+	return func(b *StoryBuilder) {
+		b.pruneDeleted = true
+	}
+}
+
+// WithPruneDead drops dead comments that have no replies left.
+func WithPruneDead() StoryOption {
+	return func(b *StoryBuilder) {
+		b.pruneDead = true
+	}
 }
 
 type StoryBuilder struct {
-	client Client
+	client       Client
+	maxDepth     int
+	maxComments  int
+	maxChildren  int
+	placeholder  func(Comment) string
+	pruneDeleted bool
+	pruneDead    bool
+	threadOnly   bool
+	quickRefresh bool
 }
 
-func NewStoryBuilder(client Client) *StoryBuilder {
-	return &StoryBuilder{client: client}
+func NewStoryBuilder(client Client, opts ...StoryOption) *StoryBuilder {
+	b := &StoryBuilder{client: client, placeholder: DefaultPlaceholder}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
 }
 
 func (b *StoryBuilder) Build(itemID int) (Story, error) {
-	item, err := b.client.GetItem(itemID)
+	story, _, err := b.BuildWithSummary(itemID)
+	return story, err
+}
+
+// BuildContext builds the story like Build and stops fetching comments once
+// ctx is done.
+func (b *StoryBuilder) BuildContext(ctx context.Context, itemID int) (Story, error) {
+	return b.build(newBuild(ctx, nil), itemID)
+}
+
+// BuildWithSummary builds the story like Build and also reports which
+// comments were deleted, dead or could not be fetched.
+func (b *StoryBuilder) BuildWithSummary(itemID int) (Story, BuildSummary, error) {
+	st := newBuild(context.Background(), nil)
+	story, err := b.build(st, itemID)
+
+	return story, st.summary, err
+}
+
+func (b *StoryBuilder) build(st *build, itemID int) (Story, error) {
+	item, err := b.getItem(st.ctx, itemID)
 	if err != nil {
 		return Story{}, err
 	}
 
-	var comments []Comment
-	for _, k := range item.Kids {
-		i, e := b.client.GetItem(k)
-		comment := Comment{
-			Id:     i.Id,
-			Text:   i.Text,
-			Author: i.Author,
+	story := b.story(st, item)
+	if err := st.send(Event{Type: EventStory, Story: story}); err != nil {
+		return Story{}, err
+	}
+	b.fill(st, story.Id, &story.Comments, &story.More, item.Kids)
+	story.Comments = b.prune(story.Comments, &st.summary)
+
+	return story, st.err
+}
+
+func storyFromItem(item Item) Story {
+	return Story{
+		Id:          item.Id,
+		Author:      item.Author,
+		Title:       item.Title,
+		Url:         item.Url,
+		Score:       item.Score,
+		Descendants: item.Descendants,
+		Time:        item.Time,
+		Text:        item.Text,
+		Kids:        item.Kids,
+	}
+}
+
+// Expand fetches the replies that a limited Build left out for the node with
+// the given ID, which is either the story itself or one of its comments. The
+// builder limits apply to the expanded subtree as if it were a fresh build
+// rooted at that node.
+func (b *StoryBuilder) Expand(story *Story, nodeID int) (BuildSummary, error) {
+	st := newBuild(context.Background(), nil)
+	if nodeID == story.Id {
+		kids := story.More
+		story.More = nil
+		b.fill(st, nodeID, &story.Comments, &story.More, kids)
+		story.Comments = b.prune(story.Comments, &st.summary)
+		return st.summary, nil
+	}
+
+	comment := story.Find(nodeID)
+	if comment == nil {
+		return st.summary, fmt.Errorf("comment %d not found in story %d", nodeID, story.Id)
+	}
+
+	kids := comment.More
+	comment.More = nil
+	b.fill(st, nodeID, &comment.ChildComments, &comment.More, kids)
+	comment.ChildComments = b.prune(comment.ChildComments, &st.summary)
+
+	return st.summary, nil
+}
+
+// build carries the state of a single Build, BuildFrom, Expand or Stream
+// call. Once err is set no more items are fetched.
+type build struct {
+	ctx     context.Context
+	emit    func(Event) error
+	summary BuildSummary
+	err     error
+}
+
+func newBuild(ctx context.Context, emit func(Event) error) *build {
+	return &build{ctx: ctx, emit: emit}
+}
+
+func (st *build) send(e Event) error {
+	if st.err == nil && st.emit != nil {
+		st.err = st.emit(e)
+	}
+
+	return st.err
+}
+
+func (st *build) stopped() bool {
+	if st.err == nil {
+		st.err = st.ctx.Err()
+	}
+
+	return st.err != nil
+}
+
+// node is a place in the tree waiting for its replies to be fetched.
+type node struct {
+	id       int
+	children *[]Comment
+	more     *[]int
+	kids     []int
+	depth    int
+}
+
+// fill fetches kids into children breadth-first, so that when a limit kicks
+// in the shallow comments are the ones that were kept. Everything left out,
+// including what was not reached before the build stopped, is recorded in
+// the more list of the node it belongs to.
+func (b *StoryBuilder) fill(st *build, parentID int, children *[]Comment, more *[]int, kids []int) {
+	budget := b.maxComments
+	queue := []node{{id: parentID, children: children, more: more, kids: kids, depth: 1}}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		take := len(n.kids)
+		if b.maxDepth > 0 && n.depth > b.maxDepth {
+			take = 0
 		}
-		if e != nil {
-			comment.Id = k
-			comment.Text = "[[Comment not found]]"
+		if b.maxChildren > 0 && take > b.maxChildren {
+			take = b.maxChildren
 		}
+		if b.maxComments > 0 && take > budget {
+			take = budget
+		}
+		budget -= take
+
+		// The slice must not grow once child nodes point into it.
+		start := len(*n.children)
+		fetched := make([]Comment, start, start+take)
+		copy(fetched, *n.children)
+		var grandKids [][]int
+		for _, k := range n.kids[:take] {
+			if st.stopped() {
+				break
+			}
+			comment, kk, ok := b.fetchComment(st, k)
+			if !ok {
+				break
+			}
+			st.summary.record(comment)
+			fetched = append(fetched, comment)
+			grandKids = append(grandKids, kk)
 
-		comments = append(comments, comment)
+			e := Event{Type: EventComment, Comment: comment, ParentID: n.id, Depth: n.depth}
+			if comment.Status == StatusFailed {
+				e.Type = EventFailed
+			}
+			st.send(e)
+		}
+		if done := len(grandKids); done < len(n.kids) {
+			*n.more = append(*n.more, n.kids[done:]...)
+		}
+		if len(grandKids) == 0 {
+			continue
+		}
+		*n.children = fetched
+
+		for i := range grandKids {
+			c := &fetched[start+i]
+			queue = append(queue, node{
+				id:       c.Id,
+				children: &c.ChildComments,
+				more:     &c.More,
+				kids:     grandKids[i],
+				depth:    n.depth + 1,
+			})
+		}
 	}
+}
 
-	return Story{
-		Id:       item.Id,
-		Author:   item.Author,
-		Title:    item.Title,
-		Comments: comments,
-	}, nil
+// getItem prefers the context aware client method when it is available.
+func (b *StoryBuilder) getItem(ctx context.Context, itemID int) (Item, error) {
+	var item Item
+	var err error
+	if c, ok := b.client.(ContextClient); ok {
+		item, err = c.GetItemContext(ctx, itemID)
+	} else if err = ctx.Err(); err == nil {
+		item, err = b.client.GetItem(itemID)
+	}
+	if err == nil && item.Id == 0 {
+		err = ErrItemNotFound
+	}
+
+	return item, err
+}
+
+// fetchComment returns the comment and the IDs of its replies. It reports
+// false when the fetch was cut short by the build being cancelled.
+func (b *StoryBuilder) fetchComment(st *build, itemID int) (Comment, []int, bool) {
+	i, e := b.getItem(st.ctx, itemID)
+	if e != nil {
+		if st.stopped() {
+			return Comment{}, nil, false
+		}
+		return b.placehold(Comment{Id: itemID, Status: StatusFailed, Err: e}), nil, true
+	}
+
+	return b.commentFromItem(i), i.Kids, true
+}
+
+func (b *StoryBuilder) commentFromItem(i Item) Comment {
+	comment := Comment{
+		Id:     i.Id,
+		Text:   i.Text,
+		Author: i.Author,
+		Time:   i.Time,
+	}
+	switch {
+	case i.Deleted:
+		comment.Status = StatusDeleted
+	case i.Dead:
+		comment.Status = StatusDead
+	}
+
+	return b.placehold(comment)
+}
+
+func (b *StoryBuilder) placehold(c Comment) Comment {
+	if c.Status != StatusOK && b.placeholder != nil {
+		c.Text = b.placeholder(c)
+	}
+
+	return c
+}
+
+// prune removes deleted and dead leaves as configured. A comment whose
+// replies all get pruned becomes a leaf itself and is considered again.
+func (b *StoryBuilder) prune(comments []Comment, summary *BuildSummary) []Comment {
+	if !b.pruneDeleted && !b.pruneDead {
+		return comments
+	}
+
+	var kept []Comment
+	for _, c := range comments {
+		c.ChildComments = b.prune(c.ChildComments, summary)
+		leaf := len(c.ChildComments) == 0 && len(c.More) == 0
+		if leaf && !c.Highlighted && (b.pruneDeleted && c.Status == StatusDeleted || b.pruneDead && c.Status == StatusDead) {
+			summary.Pruned++
+			continue
+		}
+		kept = append(kept, c)
+	}
+
+	return kept
 }
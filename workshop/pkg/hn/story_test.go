@@ -43,9 +43,13 @@ func TestStoryBuilder(t *testing.T) {
 		assert.NoError(t, err)
 
 		expectedStory := hn.Story{
-			Id:     8863,
-			Author: "dhouston",
-			Title:  "My YC app: Dropbox - Throw away your USB drive",
+			Id:          8863,
+			Author:      "dhouston",
+			Title:       "My YC app: Dropbox - Throw away your USB drive",
+			Url:         "http://www.getdropbox.com/u/2/screencast.html",
+			Score:       104,
+			Descendants: 71,
+			Time:        1175714200,
 		}
 		assert.Equal(t, expectedStory, story)
 	})
@@ -57,27 +61,37 @@ func TestStoryBuilder(t *testing.T) {
 		storyItemID := 4324234
 		client := mock.NewMockClient(ctrl)
 
+		notFound := fmt.Errorf("Comment not found")
 		rootItem := getItemFromTestData(t, "item")
 		client.EXPECT().GetItem(storyItemID).Return(rootItem, nil)
-		client.EXPECT().GetItem(9224).Return(hn.Item{}, fmt.Errorf("Comment not found"))
-		client.EXPECT().GetItem(8917).Return(hn.Item{}, fmt.Errorf("Comment not found"))
+		client.EXPECT().GetItem(9224).Return(hn.Item{}, notFound)
+		client.EXPECT().GetItem(8917).Return(hn.Item{}, notFound)
 
 		storyBuilder := hn.NewStoryBuilder(client)
 		story, err := storyBuilder.Build(storyItemID)
 		assert.NoError(t, err)
 
 		expectedStory := hn.Story{
-			Id:     8863,
-			Author: "dhouston",
-			Title:  "My YC app: Dropbox - Throw away your USB drive",
+			Id:          8863,
+			Author:      "dhouston",
+			Title:       "My YC app: Dropbox - Throw away your USB drive",
+			Url:         "http://www.getdropbox.com/u/2/screencast.html",
+			Score:       104,
+			Descendants: 71,
+			Time:        1175714200,
+			Kids:        []int{9224, 8917},
 			Comments: []hn.Comment{
 				{
-					Id:   9224,
-					Text: "[[Comment not found]]",
+					Id:     9224,
+					Text:   "[[Comment not found]]",
+					Status: hn.StatusFailed,
+					Err:    notFound,
 				},
 				{
-					Id:   8917,
-					Text: "[[Comment not found]]",
+					Id:     8917,
+					Text:   "[[Comment not found]]",
+					Status: hn.StatusFailed,
+					Err:    notFound,
 				},
 			},
 		}
@@ -100,14 +114,20 @@ func TestStoryBuilder(t *testing.T) {
 		assert.NoError(t, err)
 
 		expectedStory := hn.Story{
-			Id:     8863,
-			Author: "dhouston",
-			Title:  "My YC app: Dropbox - Throw away your USB drive",
+			Id:          8863,
+			Author:      "dhouston",
+			Title:       "My YC app: Dropbox - Throw away your USB drive",
+			Url:         "http://www.getdropbox.com/u/2/screencast.html",
+			Score:       104,
+			Descendants: 71,
+			Time:        1175714200,
+			Kids:        []int{8917},
 			Comments: []hn.Comment{
 				{
 					Id:     2921984,
 					Text:   "Title #2",
 					Author: "Wilduck",
+					Time:   1314211161,
 				},
 			},
 		}
@@ -115,7 +135,6 @@ func TestStoryBuilder(t *testing.T) {
 	})
 
 	t.Run("success, multi children recursive", func(t *testing.T) {
-		t.Skip()
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -133,17 +152,26 @@ func TestStoryBuilder(t *testing.T) {
 		assert.NoError(t, err)
 
 		expectedStory := hn.Story{
-			Id:     8863,
-			Author: "dhouston",
-			Title:  "My YC app: Dropbox - Throw away your USB drive",
+			Id:          8863,
+			Author:      "dhouston",
+			Title:       "My YC app: Dropbox - Throw away your USB drive",
+			Url:         "http://www.getdropbox.com/u/2/screencast.html",
+			Score:       104,
+			Descendants: 71,
+			Time:        1175714200,
+			Kids:        []int{9224, 8917},
 			Comments: []hn.Comment{
 				{
 					Id:     2921983,
 					Text:   "Title #1",
 					Author: "norvig",
+					Time:   1314211127,
 					ChildComments: []hn.Comment{
 						{
-							Id: 2922097,
+							Id:     2922097,
+							Text:   "Title #3",
+							Author: "Wilduck",
+							Time:   1314211161,
 						},
 					},
 				},
@@ -151,24 +179,313 @@ func TestStoryBuilder(t *testing.T) {
 					Id:     2921984,
 					Text:   "Title #2",
 					Author: "Wilduck",
+					Time:   1314211161,
 				},
 			},
 		}
 		assert.Equal(t, expectedStory, story)
 	})
+
+	t.Run("story text", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(20).Return(hn.Item{Id: 20, Type: "story", Title: "Ask HN: The Arc Effect", Text: "Is it <i>really</i> worth it?"}, nil)
+
+		story, err := hn.NewStoryBuilder(client).Build(20)
+		assert.NoError(t, err)
+		assert.Equal(t, "Is it <i>really</i> worth it?", story.Text)
+	})
+}
+
+func TestStoryBuilder_Limits(t *testing.T) {
+	storyItemID := 4324234
+
+	t.Run("max depth", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_2"), nil)
+
+		story, err := hn.NewStoryBuilder(client, hn.WithMaxDepth(1)).Build(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Len(t, story.Comments, 2)
+		assert.Empty(t, story.Comments[0].ChildComments)
+		assert.Equal(t, []int{2922097}, story.Comments[0].More)
+		assert.True(t, story.Comments[0].Truncated())
+		assert.False(t, story.Comments[1].Truncated())
+		assert.False(t, story.Truncated())
+	})
+
+	t.Run("max children", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+		client.EXPECT().GetItem(2922097).Return(getItemFromTestData(t, "child_3"), nil)
+
+		story, err := hn.NewStoryBuilder(client, hn.WithMaxChildren(1)).Build(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Len(t, story.Comments, 1)
+		assert.Len(t, story.Comments[0].ChildComments, 1)
+		assert.Equal(t, []int{8917}, story.More)
+	})
+
+	t.Run("max comments keeps shallow comments", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_2"), nil)
+
+		story, err := hn.NewStoryBuilder(client, hn.WithMaxComments(2)).Build(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Len(t, story.Comments, 2)
+		assert.Equal(t, []int{2922097}, story.Comments[0].More)
+	})
+
+	t.Run("expand truncated comment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_2"), nil)
+
+		builder := hn.NewStoryBuilder(client, hn.WithMaxDepth(1))
+		story, err := builder.Build(storyItemID)
+		assert.NoError(t, err)
+
+		client.EXPECT().GetItem(2922097).Return(getItemFromTestData(t, "child_3"), nil)
+		summary, err := builder.Expand(&story, 2921983)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.Fetched)
+
+		assert.False(t, story.Comments[0].Truncated())
+		assert.Equal(t, []hn.Comment{{Id: 2922097, Text: "Title #3", Author: "Wilduck", Time: 1314211161}}, story.Comments[0].ChildComments)
+	})
+
+	t.Run("expand truncated story", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_1"), nil)
+
+		builder := hn.NewStoryBuilder(client, hn.WithMaxChildren(1), hn.WithMaxDepth(1))
+		story, err := builder.Build(storyItemID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{8917}, story.More)
+
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_2"), nil)
+		_, err = builder.Expand(&story, story.Id)
+		assert.NoError(t, err)
+
+		assert.False(t, story.Truncated())
+		assert.Len(t, story.Comments, 2)
+		assert.Equal(t, 2921984, story.Comments[1].Id)
+		assert.Equal(t, []int{2922097}, story.Comments[0].More)
+	})
+
+	t.Run("expand unknown comment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		story := hn.Story{Id: 8863}
+		_, err := hn.NewStoryBuilder(mock.NewMockClient(ctrl)).Expand(&story, 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestStoryBuilder_Status(t *testing.T) {
+	storyItemID := 4324234
+
+	t.Run("deleted and dead comments", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_deleted"), nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_dead"), nil)
+
+		story, summary, err := hn.NewStoryBuilder(client, hn.WithPlaceholder(nil)).BuildWithSummary(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []hn.Comment{
+			{Id: 9224, Time: 1175714300, Status: hn.StatusDeleted},
+			{Id: 8917, Author: "spammer", Text: "Buy cheap watches", Time: 1175714350, Status: hn.StatusDead},
+		}, story.Comments)
+		assert.Equal(t, hn.BuildSummary{Fetched: 2, Deleted: 1, Dead: 1}, summary)
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_deleted"), nil)
+		client.EXPECT().GetItem(8917).Return(hn.Item{}, fmt.Errorf("Comment not found"))
+
+		story, err := hn.NewStoryBuilder(client).Build(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "[deleted]", story.Comments[0].Text)
+		assert.Equal(t, "[[Comment not found]]", story.Comments[1].Text)
+
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_deleted"), nil)
+		client.EXPECT().GetItem(8917).Return(hn.Item{}, fmt.Errorf("Comment not found"))
+
+		story, err = hn.NewStoryBuilder(client, hn.WithPlaceholder(func(c hn.Comment) string { return "gone" })).Build(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "gone", story.Comments[0].Text)
+		assert.Equal(t, "gone", story.Comments[1].Text)
+	})
+
+	t.Run("missing comment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item_single_child"), nil)
+		client.EXPECT().GetItem(8917).Return(hn.Item{}, nil)
+
+		story, summary, err := hn.NewStoryBuilder(client).BuildWithSummary(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, hn.StatusFailed, story.Comments[0].Status)
+		assert.Equal(t, []hn.FetchFailure{{Id: 8917, Err: hn.ErrItemNotFound}}, summary.Failures)
+	})
+
+	t.Run("prune deleted leaves", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(getItemFromTestData(t, "child_deleted"), nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_dead"), nil)
+
+		story, summary, err := hn.NewStoryBuilder(client, hn.WithPruneDeleted()).BuildWithSummary(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Len(t, story.Comments, 1)
+		assert.Equal(t, hn.StatusDead, story.Comments[0].Status)
+		assert.Equal(t, 1, summary.Pruned)
+	})
+
+	t.Run("prune keeps deleted comments with replies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		deleted := getItemFromTestData(t, "child_deleted")
+		deleted.Kids = []int{2922097}
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(storyItemID).Return(getItemFromTestData(t, "item"), nil)
+		client.EXPECT().GetItem(9224).Return(deleted, nil)
+		client.EXPECT().GetItem(8917).Return(getItemFromTestData(t, "child_dead"), nil)
+		client.EXPECT().GetItem(2922097).Return(getItemFromTestData(t, "child_3"), nil)
+
+		builder := hn.NewStoryBuilder(client, hn.WithPruneDeleted(), hn.WithPruneDead())
+		story, err := builder.Build(storyItemID)
+		assert.NoError(t, err)
+
+		assert.Len(t, story.Comments, 1)
+		assert.Equal(t, hn.StatusDeleted, story.Comments[0].Status)
+		assert.Len(t, story.Comments[0].ChildComments, 1)
+	})
+}
+
+func TestStoryBuilder_Poll(t *testing.T) {
+	t.Run("poll options", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(126809).Return(getItemFromTestData(t, "poll"), nil)
+		client.EXPECT().GetItem(126810).Return(getItemFromTestData(t, "pollopt_1"), nil)
+		client.EXPECT().GetItem(126811).Return(getItemFromTestData(t, "pollopt_2"), nil)
+		client.EXPECT().GetItem(126812).Return(getItemFromTestData(t, "pollopt_3"), nil)
+		client.EXPECT().GetItem(126822).Return(getItemFromTestData(t, "child_2"), nil)
+
+		story, err := hn.NewStoryBuilder(client).Build(126809)
+		assert.NoError(t, err)
+
+		assert.Len(t, story.Comments, 1)
+		assert.Equal(t, &hn.Poll{
+			Votes: 500,
+			Options: []hn.PollOption{
+				{Id: 126810, Text: "It would be a good thing.", Score: 335, Percent: 67},
+				{Id: 126811, Text: "It would be a bad thing.", Score: 117, Percent: 23.4},
+				{Id: 126812, Text: "It would make no difference.", Score: 48, Percent: 9.6},
+			},
+		}, story.Poll)
+	})
+
+	t.Run("missing option", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		poll := getItemFromTestData(t, "poll")
+		poll.Kids = nil
+		poll.Parts = []int{126810, 126811}
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(126809).Return(poll, nil)
+		client.EXPECT().GetItem(126810).Return(getItemFromTestData(t, "pollopt_1"), nil)
+		client.EXPECT().GetItem(126811).Return(hn.Item{}, fmt.Errorf("Option not found"))
+
+		story, summary, err := hn.NewStoryBuilder(client).BuildWithSummary(126809)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []hn.PollOption{
+			{Id: 126810, Text: "It would be a good thing.", Score: 335, Percent: 100},
+			{Id: 126811, Failed: true},
+		}, story.Poll.Options)
+		assert.Len(t, summary.Failures, 1)
+	})
+
+	t.Run("stories have no poll", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(8863).Return(getItemFromTestData(t, "item_no_children"), nil)
+
+		story, err := hn.NewStoryBuilder(client).Build(8863)
+		assert.NoError(t, err)
+		assert.Nil(t, story.Poll)
+	})
 }
 
 func getItemFromTestData(t *testing.T, filename string) hn.Item {
 	t.Helper()
 	file, err := ioutil.ReadFile(fmt.Sprintf("testdata/%s.json", filename))
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Fatal(err)
 	}
 
 	var item hn.Item
 	err = json.Unmarshal(file, &item)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Fatal(err)
 	}
 
 	return item
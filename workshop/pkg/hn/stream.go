@@ -0,0 +1,88 @@
+package hn
+
+import "context"
+
+// ContextClient is implemented by clients whose requests can be cancelled.
+// StoryBuilder uses it when available so that cancelling a stream also
+// aborts the fetch in flight.
+type ContextClient interface {
+	GetItemContext(ctx context.Context, itemID int) (Item, error)
+}
+
+// EventType identifies what a streamed Event carries.
+type EventType int
+
+const (
+	// EventStory carries the story header, without comments.
+	EventStory EventType = iota
+	// EventComment carries a fetched comment, without its replies.
+	EventComment
+	// EventFailed carries a comment that could not be fetched.
+	EventFailed
+	// EventDone carries the finished story, or the error that ended the build.
+	EventDone
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStory:
+		return "story"
+	case EventComment:
+		return "comment"
+	case EventFailed:
+		return "failed"
+	case EventDone:
+		return "done"
+	}
+
+	return "unknown"
+}
+
+// Event is emitted by Stream as the comment tree is fetched. Comments are
+// emitted breadth-first, so a parent always arrives before its replies.
+type Event struct {
+	Type     EventType
+	Story    Story
+	Comment  Comment
+	ParentID int
+	Depth    int
+	Summary  BuildSummary
+	Err      error
+}
+
+// Stream builds the story like Build while calling emit for every fetched
+// item. Returning an error from emit stops the build, and so does cancelling
+// ctx. The final EventDone holds whatever was built so far, with unfetched
+// replies left in the More lists.
+func (b *StoryBuilder) Stream(ctx context.Context, itemID int, emit func(Event) error) error {
+	st := newBuild(ctx, emit)
+	story, err := b.build(st, itemID)
+
+	if emit != nil {
+		if e := emit(Event{Type: EventDone, Story: story, Summary: st.summary, Err: err}); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// StreamEvents runs Stream in a goroutine and delivers its events on the
+// returned channel, which is closed when the build ends. Once ctx is
+// cancelled pending events, including EventDone, may be dropped.
+func (b *StoryBuilder) StreamEvents(ctx context.Context, itemID int) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		_ = b.Stream(ctx, itemID, func(e Event) error {
+			select {
+			case events <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return events
+}
@@ -0,0 +1,152 @@
+package hn_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type streamed struct {
+	Type     hn.EventType
+	Id       int
+	ParentID int
+	Depth    int
+}
+
+func TestStoryBuilder_Stream(t *testing.T) {
+	items := threadItems()
+
+	t.Run("emits every comment after its parent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 100, 101, 102, 103, 104, 105, 106)
+
+		var events []streamed
+		var done hn.Event
+		err := hn.NewStoryBuilder(client).Stream(context.Background(), 100, func(e hn.Event) error {
+			switch e.Type {
+			case hn.EventStory:
+				events = append(events, streamed{Type: e.Type, Id: e.Story.Id})
+			case hn.EventDone:
+				done = e
+			default:
+				events = append(events, streamed{e.Type, e.Comment.Id, e.ParentID, e.Depth})
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []streamed{
+			{hn.EventStory, 100, 0, 0},
+			{hn.EventComment, 101, 100, 1},
+			{hn.EventComment, 102, 100, 1},
+			{hn.EventComment, 103, 101, 2},
+			{hn.EventComment, 104, 101, 2},
+			{hn.EventComment, 105, 102, 2},
+			{hn.EventComment, 106, 103, 3},
+		}, events)
+		assert.NoError(t, done.Err)
+		assert.Equal(t, 6, done.Summary.Fetched)
+		assert.Equal(t, 106, done.Story.Comments[0].ChildComments[0].ChildComments[0].Id)
+	})
+
+	t.Run("failed comment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 102)
+		client.EXPECT().GetItem(4324234).Return(hn.Item{Id: 4324234, Kids: []int{101, 102}}, nil)
+		client.EXPECT().GetItem(101).Return(hn.Item{}, fmt.Errorf("Comment not found"))
+		expectItems(client, items, 105)
+
+		var failed []int
+		err := hn.NewStoryBuilder(client).Stream(context.Background(), 4324234, func(e hn.Event) error {
+			if e.Type == hn.EventFailed {
+				failed = append(failed, e.Comment.Id)
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{101}, failed)
+	})
+
+	t.Run("emit error stops the build", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 100, 101)
+
+		stop := fmt.Errorf("enough")
+		var done hn.Event
+		err := hn.NewStoryBuilder(client).Stream(context.Background(), 100, func(e hn.Event) error {
+			if e.Type == hn.EventDone {
+				done = e
+				return nil
+			}
+			if e.Type == hn.EventComment {
+				return stop
+			}
+			return nil
+		})
+
+		assert.Equal(t, stop, err)
+		assert.Equal(t, stop, done.Err)
+		assert.Len(t, done.Story.Comments, 1)
+		assert.Equal(t, []int{102}, done.Story.More)
+		assert.Equal(t, []int{103, 104}, done.Story.Comments[0].More)
+	})
+
+	t.Run("cancel stops outstanding fetches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 100, 101)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := hn.NewStoryBuilder(client).Stream(ctx, 100, func(e hn.Event) error {
+			if e.Type == hn.EventComment {
+				cancel()
+			}
+			return nil
+		})
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("build with a cancelled context", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := hn.NewStoryBuilder(mock.NewMockClient(ctrl)).BuildContext(ctx, 100)
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("channel", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 102, 105)
+
+		var types []hn.EventType
+		for e := range hn.NewStoryBuilder(client).StreamEvents(context.Background(), 102) {
+			types = append(types, e.Type)
+		}
+
+		assert.Equal(t, []hn.EventType{hn.EventStory, hn.EventComment, hn.EventDone}, types)
+	})
+}
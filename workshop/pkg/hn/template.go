@@ -0,0 +1,135 @@
+package hn
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template is satisfied by both *text/template.Template and
+// *html/template.Template.
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// TemplateTotals is passed to header and footer templates.
+type TemplateTotals struct {
+	Count int
+}
+
+type templateEncoder struct {
+	item   Template
+	header Template
+	footer Template
+}
+
+// NewTemplateEncoder executes item for every dumped Entry. The optional
+// header and footer templates receive TemplateTotals; the header is always
+// given a zero count.
+func NewTemplateEncoder(item, header, footer Template) Encoder {
+	return templateEncoder{item: item, header: header, footer: footer}
+}
+
+func (t templateEncoder) Header(w io.Writer) error {
+	if t.header == nil {
+		return nil
+	}
+
+	return t.header.Execute(w, TemplateTotals{})
+}
+
+func (t templateEncoder) Encode(w io.Writer, e Entry, n int) error {
+	return t.item.Execute(w, e)
+}
+
+func (t templateEncoder) Footer(w io.Writer, n int) error {
+	if t.footer == nil {
+		return nil
+	}
+
+	return t.footer.Execute(w, TemplateTotals{Count: n})
+}
+
+// TemplateFuncs returns the helper functions for dump templates, with now
+// as the reference for relative times:
+//
+//	domain    host name of a URL without "www."
+//	ago       unix time as "3 hours ago"
+//	truncate  shortens a string to n runes, as in {{.Title | truncate 40}}
+//	unescape  decodes HTML entities in titles and texts
+//	itemurl   link to an item on the HN site
+//
+// The map can be passed to Funcs of both template packages.
+func TemplateFuncs(now func() time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"domain": Domain,
+		"ago": func(unix int64) string {
+			return ago(now(), time.Unix(unix, 0))
+		},
+		"truncate": Truncate,
+		"unescape": html.UnescapeString,
+		"itemurl":  itemURL,
+	}
+}
+
+// ParseTemplate parses a text/template with TemplateFuncs registered.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs(time.Now)).Parse(text)
+}
+
+// Domain returns the host name of a URL, lower case and without "www.".
+func Domain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func ago(now, then time.Time) string {
+	d := now.Sub(then)
+	if d < time.Minute {
+		return "just now"
+	}
+
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{365 * 24 * time.Hour, "year"},
+		{30 * 24 * time.Hour, "month"},
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+	for _, u := range units {
+		if d >= u.size {
+			n := int(d / u.size)
+			if n == 1 {
+				return fmt.Sprintf("1 %s ago", u.name)
+			}
+			return fmt.Sprintf("%d %ss ago", n, u.name)
+		}
+	}
+
+	return "just now"
+}
+
+// Truncate shortens s to n runes, ending it with an ellipsis when cut.
+func Truncate(n int, s string) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
+
+func itemURL(id int) string {
+	return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
+}
@@ -0,0 +1,85 @@
+package hn_test
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"testing"
+	"text/template"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateEncoder(t *testing.T) {
+	now := time.Unix(1175714200, 0).Add(3 * time.Hour)
+	funcs := hn.TemplateFuncs(func() time.Time { return now })
+	entry := hn.Entry{Item: getItemFromTestData(t, "item")}
+
+	t.Run("helpers", func(t *testing.T) {
+		tmpl := template.Must(template.New("item").Funcs(funcs).Parse(
+			`{{.Title | truncate 12}} ({{domain .Url}}) by {{.Author}} {{ago .Time}} {{itemurl .Id}}` + "\n"))
+
+		out := encodeEntries(t, hn.NewTemplateEncoder(tmpl, nil, nil), entry)
+		assert.Equal(t, "My YC app: … (getdropbox.com) by dhouston 3 hours ago https://news.ycombinator.com/item?id=8863\n", out)
+	})
+
+	t.Run("unescape", func(t *testing.T) {
+		tmpl := template.Must(template.New("item").Funcs(funcs).Parse(`{{unescape .Title}}`))
+		escaped := hn.Entry{Item: hn.Item{Title: "Fish &amp; Chips &#x27;24"}}
+
+		out := encodeEntries(t, hn.NewTemplateEncoder(tmpl, nil, nil), escaped)
+		assert.Equal(t, "Fish & Chips '24", out)
+	})
+
+	t.Run("relative times", func(t *testing.T) {
+		tmpl := template.Must(template.New("item").Funcs(funcs).Parse(`{{ago .Time}};`))
+		entries := []hn.Entry{
+			{Item: hn.Item{Time: now.Unix() - 10}},
+			{Item: hn.Item{Time: now.Unix() - 60}},
+			{Item: hn.Item{Time: now.Unix() - 5*60}},
+			{Item: hn.Item{Time: now.Unix() - 2*24*3600}},
+			{Item: hn.Item{Time: now.Unix() - 400*24*3600}},
+		}
+
+		out := encodeEntries(t, hn.NewTemplateEncoder(tmpl, nil, nil), entries...)
+		assert.Equal(t, "just now;1 minute ago;5 minutes ago;2 days ago;1 year ago;", out)
+	})
+
+	t.Run("header and footer", func(t *testing.T) {
+		item := template.Must(template.New("item").Parse("- {{.Title}}\n"))
+		header := template.Must(template.New("header").Parse("*Today on HN*\n"))
+		footer := template.Must(template.New("footer").Parse("{{.Count}} stories\n"))
+
+		out := encodeEntries(t, hn.NewTemplateEncoder(item, header, footer), entry, entry)
+		assert.Equal(t, "*Today on HN*\n- My YC app: Dropbox - Throw away your USB drive\n- My YC app: Dropbox - Throw away your USB drive\n2 stories\n", out)
+	})
+
+	t.Run("html template", func(t *testing.T) {
+		tmpl := htmltemplate.Must(htmltemplate.New("item").Funcs(funcs).Parse(`<a href="{{.Url}}">{{.Title}}</a>`))
+		escaped := hn.Entry{Item: hn.Item{Title: "<b>bold</b>", Url: "http://example.com/?a=1&b=2"}}
+
+		out := encodeEntries(t, hn.NewTemplateEncoder(tmpl, nil, nil), escaped)
+		assert.Equal(t, `<a href="http://example.com/?a=1&amp;b=2">&lt;b&gt;bold&lt;/b&gt;</a>`, out)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().MaxItem().Return(5, nil)
+		client.EXPECT().GetItem(5).Return(getItem(5), nil)
+		client.EXPECT().GetItem(4).Return(getItem(4), nil)
+
+		tmpl, err := hn.ParseTemplate("item", "{{.Score}}\t{{.Title}}\n")
+		assert.NoError(t, err)
+
+		var b bytes.Buffer
+		err = hn.NewDump(client, 2, hn.WithEncoder(hn.NewTemplateEncoder(tmpl, nil, nil))).Dump(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, "5\tTitle 5\n4\tTitle 4\n", b.String())
+	})
+}
@@ -0,0 +1,147 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxParentHops guards BuildFrom against parent cycles in broken data.
+const maxParentHops = 10000
+
+// WithThreadOnly makes BuildFrom fetch only the ancestors of the requested
+// comment, their siblings and the requested comment's own replies. Replies to
+// the siblings are left in their More lists.
+func WithThreadOnly() StoryOption {
+	return func(b *StoryBuilder) {
+		b.threadOnly = true
+	}
+}
+
+// BuildFrom builds the story that the given item belongs to. The item may be
+// the story itself or any comment in it; its ancestors are listed in
+// Story.Path and marked as highlighted.
+func (b *StoryBuilder) BuildFrom(itemID int) (Story, error) {
+	st := newBuild(context.Background(), nil)
+	chain, err := b.ancestors(st, itemID)
+	if err != nil {
+		return Story{}, err
+	}
+
+	root, path := chain[0], chain[1:]
+
+	var story Story
+	if b.threadOnly {
+		story = b.buildThread(st, root, path)
+	} else {
+		story = b.story(st, root)
+		b.fill(st, story.Id, &story.Comments, &story.More, root.Kids)
+		b.ensurePath(st, &story, path)
+	}
+	story.Comments = b.prune(story.Comments, &st.summary)
+
+	return story, st.err
+}
+
+// ancestors returns the items from the root story down to itemID.
+func (b *StoryBuilder) ancestors(st *build, itemID int) ([]Item, error) {
+	var chain []Item
+	seen := map[int]bool{}
+	for id := itemID; id != 0; {
+		if seen[id] || len(chain) >= maxParentHops {
+			return nil, fmt.Errorf("parent chain of item %d does not reach a story", itemID)
+		}
+		seen[id] = true
+
+		item, err := b.getItem(st.ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append([]Item{item}, chain...)
+		id = item.Parent
+	}
+
+	return chain, nil
+}
+
+// ensurePath makes sure every comment on path is present in a story built
+// with limits, moving them out of the More lists where needed.
+func (b *StoryBuilder) ensurePath(st *build, story *Story, path []Item) {
+	children, more := &story.Comments, &story.More
+	for _, item := range path {
+		story.Path = append(story.Path, item.Id)
+
+		c := findChild(*children, item.Id)
+		if c == nil {
+			*more = removeID(*more, item.Id)
+			comment := b.commentFromItem(item)
+			comment.More = item.Kids
+			st.summary.record(comment)
+			*children = append(*children, comment)
+			c = &(*children)[len(*children)-1]
+		}
+		c.Highlighted = true
+		children, more = &c.ChildComments, &c.More
+	}
+}
+
+// buildThread builds only the branch of the tree that leads to the last
+// comment on path, plus the full subtree below that comment.
+func (b *StoryBuilder) buildThread(st *build, root Item, path []Item) Story {
+	story := b.story(st, root)
+	children, more := &story.Comments, &story.More
+	parentID, kids := root.Id, root.Kids
+	for _, item := range path {
+		story.Path = append(story.Path, item.Id)
+
+		level := make([]Comment, 0, len(kids)+1)
+		onPath := -1
+		for _, k := range kids {
+			if k == item.Id {
+				onPath = len(level)
+				level = append(level, b.commentFromItem(item))
+				continue
+			}
+			sibling, siblingKids, _ := b.fetchComment(st, k)
+			sibling.More = siblingKids
+			level = append(level, sibling)
+		}
+		if onPath < 0 {
+			onPath = len(level)
+			level = append(level, b.commentFromItem(item))
+		}
+		for _, c := range level {
+			st.summary.record(c)
+		}
+
+		*children = level
+		c := &level[onPath]
+		c.Highlighted = true
+		children, more = &c.ChildComments, &c.More
+		parentID, kids = item.Id, item.Kids
+	}
+	b.fill(st, parentID, children, more, kids)
+
+	return story
+}
+
+func findChild(comments []Comment, id int) *Comment {
+	for i := range comments {
+		if comments[i].Id == id {
+			return &comments[i]
+		}
+	}
+
+	return nil
+}
+
+func removeID(ids []int, id int) []int {
+	var kept []int
+	for _, k := range ids {
+		if k != id {
+			kept = append(kept, k)
+		}
+	}
+
+	return kept
+}
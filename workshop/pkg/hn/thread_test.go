@@ -0,0 +1,119 @@
+package hn_test
+
+import (
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func threadItems() map[int]hn.Item {
+	return map[int]hn.Item{
+		100: {Id: 100, Type: "story", Author: "pg", Title: "Ask HN: Threads?", Kids: []int{101, 102}},
+		101: {Id: 101, Type: "comment", Author: "a", Text: "101", Parent: 100, Kids: []int{103, 104}},
+		102: {Id: 102, Type: "comment", Author: "b", Text: "102", Parent: 100, Kids: []int{105}},
+		103: {Id: 103, Type: "comment", Author: "c", Text: "103", Parent: 101, Kids: []int{106}},
+		104: {Id: 104, Type: "comment", Author: "d", Text: "104", Parent: 101},
+		105: {Id: 105, Type: "comment", Author: "e", Text: "105", Parent: 102},
+		106: {Id: 106, Type: "comment", Author: "f", Text: "106", Parent: 103},
+	}
+}
+
+func expectItems(client *mock.MockClient, items map[int]hn.Item, ids ...int) {
+	for _, id := range ids {
+		client.EXPECT().GetItem(id).Return(items[id], nil)
+	}
+}
+
+func TestStoryBuilder_BuildFrom(t *testing.T) {
+	items := threadItems()
+
+	t.Run("full story", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 103, 101, 100)
+		expectItems(client, items, 101, 102, 103, 104, 105, 106)
+
+		story, err := hn.NewStoryBuilder(client).BuildFrom(103)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 100, story.Id)
+		assert.Equal(t, []int{101, 103}, story.Path)
+		assert.True(t, story.Comments[0].Highlighted)
+		assert.False(t, story.Comments[1].Highlighted)
+		assert.True(t, story.Comments[0].ChildComments[0].Highlighted)
+		assert.False(t, story.Comments[0].ChildComments[1].Highlighted)
+		assert.Equal(t, 106, story.Comments[0].ChildComments[0].ChildComments[0].Id)
+		assert.Equal(t, 105, story.Comments[1].ChildComments[0].Id)
+	})
+
+	t.Run("from the story itself", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 100)
+		expectItems(client, items, 101, 102, 103, 104, 105, 106)
+
+		story, err := hn.NewStoryBuilder(client).BuildFrom(100)
+		assert.NoError(t, err)
+
+		assert.Empty(t, story.Path)
+		assert.Len(t, story.Comments, 2)
+	})
+
+	t.Run("thread only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 103, 101, 100)
+		expectItems(client, items, 102, 104, 106)
+
+		story, err := hn.NewStoryBuilder(client, hn.WithThreadOnly()).BuildFrom(103)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []int{101, 103}, story.Path)
+		assert.Equal(t, []int{105}, story.Comments[1].More)
+		assert.Empty(t, story.Comments[1].ChildComments)
+
+		target := story.Comments[0].ChildComments[0]
+		assert.Equal(t, 103, target.Id)
+		assert.True(t, target.Highlighted)
+		assert.Equal(t, []hn.Comment{{Id: 106, Author: "f", Text: "106"}}, target.ChildComments)
+	})
+
+	t.Run("path survives limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		expectItems(client, items, 106, 103, 101, 100)
+		expectItems(client, items, 101, 102)
+
+		story, err := hn.NewStoryBuilder(client, hn.WithMaxDepth(1)).BuildFrom(106)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []int{101, 103, 106}, story.Path)
+		assert.Equal(t, []int{104}, story.Comments[0].More)
+
+		middle := story.Comments[0].ChildComments[0]
+		assert.Equal(t, 103, middle.Id)
+		assert.True(t, middle.ChildComments[0].Highlighted)
+	})
+
+	t.Run("parent cycle", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock.NewMockClient(ctrl)
+		client.EXPECT().GetItem(7).Return(hn.Item{Id: 7, Parent: 7}, nil)
+
+		_, err := hn.NewStoryBuilder(client).BuildFrom(7)
+		assert.Error(t, err)
+	})
+}
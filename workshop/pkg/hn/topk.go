@@ -0,0 +1,109 @@
+package hn
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"time"
+)
+
+// SortKey selects what Dump ranks items by when WithTop is set.
+type SortKey int
+
+const (
+	SortByScore SortKey = iota
+	SortByTime
+	SortByComments
+	// SortByHotness uses the HN front page formula, score over age.
+	SortByHotness
+)
+
+// rank returns the sort value of item; higher is better.
+func (k SortKey) rank(item Item, now time.Time) float64 {
+	switch k {
+	case SortByTime:
+		return float64(item.Time)
+	case SortByComments:
+		return float64(item.Descendants)
+	case SortByHotness:
+		return hotness(item, now)
+	}
+
+	return float64(item.Score)
+}
+
+func hotness(item Item, now time.Time) float64 {
+	age := now.Sub(time.Unix(item.Time, 0)).Hours()
+	if age < 0 {
+		age = 0
+	}
+
+	return float64(item.Score-1) / math.Pow(age+2, 1.8)
+}
+
+type ranked struct {
+	item Item
+	rank float64
+}
+
+// better orders by rank, then by newer ID.
+func (r ranked) better(o ranked) bool {
+	if r.rank != o.rank {
+		return r.rank > o.rank
+	}
+
+	return r.item.Id > o.item.Id
+}
+
+// topK keeps the k best items seen so far in a min-heap, so that memory
+// stays bounded by k whatever the size of the scanned window.
+type topK struct {
+	k     int
+	key   SortKey
+	now   time.Time
+	items []ranked
+}
+
+func newTopK(k int, key SortKey, now time.Time) *topK {
+	return &topK{k: k, key: key, now: now}
+}
+
+func (t *topK) Len() int           { return len(t.items) }
+func (t *topK) Less(i, j int) bool { return t.items[j].better(t.items[i]) }
+func (t *topK) Swap(i, j int)      { t.items[i], t.items[j] = t.items[j], t.items[i] }
+
+func (t *topK) Push(x interface{}) {
+	t.items = append(t.items, x.(ranked))
+}
+
+func (t *topK) Pop() interface{} {
+	last := t.items[len(t.items)-1]
+	t.items = t.items[:len(t.items)-1]
+
+	return last
+}
+
+func (t *topK) offer(item Item) {
+	r := ranked{item: item, rank: t.key.rank(item, t.now)}
+	if len(t.items) < t.k {
+		heap.Push(t, r)
+		return
+	}
+	if r.better(t.items[0]) {
+		t.items[0] = r
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted returns the kept items, best first.
+func (t *topK) sorted() []Item {
+	ranks := append([]ranked(nil), t.items...)
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i].better(ranks[j]) })
+
+	items := make([]Item, len(ranks))
+	for i, r := range ranks {
+		items[i] = r.item
+	}
+
+	return items
+}
@@ -0,0 +1,61 @@
+package hn_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumper_Top(t *testing.T) {
+	now := time.Date(2019, 8, 8, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(h int) int64 { return now.Add(-time.Duration(h) * time.Hour).Unix() }
+	items := map[int]hn.Item{
+		10: {Id: 10, Type: "story", Title: "Fresh", Score: 10, Descendants: 1, Time: hoursAgo(1)},
+		9:  {Id: 9, Type: "comment", Author: "a", Time: hoursAgo(2)},
+		8:  {Id: 8, Type: "story", Title: "Popular", Score: 300, Descendants: 20, Time: hoursAgo(20)},
+		7:  {Id: 7, Type: "story", Title: "Discussed", Score: 40, Descendants: 90, Time: hoursAgo(3)},
+		6:  {Id: 6, Type: "story", Title: "Old", Score: 400, Descendants: 5, Time: hoursAgo(200)},
+		5:  {Id: 5, Type: "story", Title: "Tied", Score: 40, Time: hoursAgo(201)},
+	}
+	stories := hn.Filter{Types: []string{"story"}}
+
+	cases := []struct {
+		name     string
+		limit    int
+		k        int
+		key      hn.SortKey
+		expected string
+	}{
+		{"by score", 5, 3, hn.SortByScore, "Old,400\nPopular,300\nDiscussed,40\n"},
+		{"ties go to newer items", 5, 4, hn.SortByScore, "Old,400\nPopular,300\nDiscussed,40\nTied,40\n"},
+		{"by time", 5, 2, hn.SortByTime, "Fresh,10\nDiscussed,40\n"},
+		{"by comments", 5, 2, hn.SortByComments, "Discussed,40\nPopular,300\n"},
+		{"by hotness", 5, 3, hn.SortByHotness, "Discussed,40\nFresh,10\nPopular,300\n"},
+		{"window smaller than k", 2, 5, hn.SortByScore, "Popular,300\nFresh,10\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := mock.NewMockClient(ctrl)
+			client.EXPECT().MaxItem().Return(10, nil)
+			serveItems(client, items)
+
+			var b bytes.Buffer
+			dump := hn.NewDump(client, c.limit,
+				hn.WithFilter(stories),
+				hn.WithTop(c.k, c.key),
+				hn.WithClock(func() time.Time { return now }))
+			err := dump.Dump(&b)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, b.String())
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package hn
+
+import "errors"
+
+// SkipSubtree is returned by a WalkFunc to skip the replies of the comment
+// it was called with. It is never returned by the walk itself.
+var SkipSubtree = errors.New("skip this subtree")
+
+// errStopWalk ends a walk early once the caller found what it needed.
+var errStopWalk = errors.New("stop walk")
+
+// WalkFunc is called for every comment of a walk. Top-level comments of a
+// story, or direct replies of a comment, are at depth 1. The comment may be
+// modified in place. Returning an error other than SkipSubtree stops the walk.
+type WalkFunc func(c *Comment, depth int) error
+
+// FlatComment is a comment with its position in the tree.
+type FlatComment struct {
+	Comment  Comment
+	ParentID int
+	Depth    int
+}
+
+// TreeStats summarises the shape of a comment tree.
+type TreeStats struct {
+	Count    int
+	MaxDepth int
+	// BranchingFactor is the average number of direct replies of the nodes
+	// that have any, counting the story or comment the stats were taken of.
+	BranchingFactor float64
+	// MostRepliedID is the comment with the most direct replies, or zero
+	// when no comment has replies.
+	MostRepliedID int
+	MostReplies   int
+	Authors       map[string]int
+}
+
+// Walk visits all comments depth-first, in the order they are displayed.
+func (s Story) Walk(fn WalkFunc) error {
+	return walkDepthFirst(s.Comments, 1, fn)
+}
+
+// WalkBreadthFirst visits all comments level by level.
+func (s Story) WalkBreadthFirst(fn WalkFunc) error {
+	return walkBreadthFirst(s.Comments, fn)
+}
+
+// Flatten lists all comments depth-first with their depth and parent.
+func (s Story) Flatten() []FlatComment {
+	return flatten(s.Comments, s.Id)
+}
+
+// Find returns the comment with the given ID, or nil.
+func (s Story) Find(id int) *Comment {
+	return find(s.Comments, id)
+}
+
+// FindByAuthor returns all comments written by author, depth-first.
+func (s Story) FindByAuthor(author string) []*Comment {
+	return findByAuthor(s.Comments, author)
+}
+
+// Stats computes statistics over all comments of the story.
+func (s Story) Stats() TreeStats {
+	return stats(s.Comments)
+}
+
+// Walk visits the replies of c depth-first.
+func (c Comment) Walk(fn WalkFunc) error {
+	return walkDepthFirst(c.ChildComments, 1, fn)
+}
+
+// WalkBreadthFirst visits the replies of c level by level.
+func (c Comment) WalkBreadthFirst(fn WalkFunc) error {
+	return walkBreadthFirst(c.ChildComments, fn)
+}
+
+// Flatten lists the replies of c depth-first with their depth and parent.
+func (c Comment) Flatten() []FlatComment {
+	return flatten(c.ChildComments, c.Id)
+}
+
+// Find returns the reply of c with the given ID, at any depth, or nil.
+func (c Comment) Find(id int) *Comment {
+	return find(c.ChildComments, id)
+}
+
+// FindByAuthor returns the replies of c written by author, depth-first.
+func (c Comment) FindByAuthor(author string) []*Comment {
+	return findByAuthor(c.ChildComments, author)
+}
+
+// Stats computes statistics over the replies of c.
+func (c Comment) Stats() TreeStats {
+	return stats(c.ChildComments)
+}
+
+func walkDepthFirst(comments []Comment, depth int, fn WalkFunc) error {
+	for i := range comments {
+		err := fn(&comments[i], depth)
+		if err == SkipSubtree {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := walkDepthFirst(comments[i].ChildComments, depth+1, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkBreadthFirst(comments []Comment, fn WalkFunc) error {
+	level := make([]*Comment, len(comments))
+	for i := range comments {
+		level[i] = &comments[i]
+	}
+
+	for depth := 1; len(level) > 0; depth++ {
+		var next []*Comment
+		for _, c := range level {
+			err := fn(c, depth)
+			if err == SkipSubtree {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			for i := range c.ChildComments {
+				next = append(next, &c.ChildComments[i])
+			}
+		}
+		level = next
+	}
+
+	return nil
+}
+
+func flatten(comments []Comment, parentID int) []FlatComment {
+	var flat []FlatComment
+	parents := []int{parentID}
+	_ = walkDepthFirst(comments, 1, func(c *Comment, depth int) error {
+		parents = append(parents[:depth], c.Id)
+		flat = append(flat, FlatComment{Comment: *c, ParentID: parents[depth-1], Depth: depth})
+		return nil
+	})
+
+	return flat
+}
+
+func find(comments []Comment, id int) *Comment {
+	var found *Comment
+	_ = walkDepthFirst(comments, 1, func(c *Comment, depth int) error {
+		if c.Id == id {
+			found = c
+			return errStopWalk
+		}
+		return nil
+	})
+
+	return found
+}
+
+func findByAuthor(comments []Comment, author string) []*Comment {
+	var found []*Comment
+	_ = walkDepthFirst(comments, 1, func(c *Comment, depth int) error {
+		if c.Author == author {
+			found = append(found, c)
+		}
+		return nil
+	})
+
+	return found
+}
+
+func stats(comments []Comment) TreeStats {
+	s := TreeStats{Authors: map[string]int{}}
+	parents, replies := 0, 0
+	if len(comments) > 0 {
+		parents, replies = 1, len(comments)
+	}
+
+	_ = walkDepthFirst(comments, 1, func(c *Comment, depth int) error {
+		s.Count++
+		if depth > s.MaxDepth {
+			s.MaxDepth = depth
+		}
+		if c.Author != "" {
+			s.Authors[c.Author]++
+		}
+		if n := len(c.ChildComments); n > 0 {
+			parents++
+			replies += n
+			if n > s.MostReplies {
+				s.MostRepliedID, s.MostReplies = c.Id, n
+			}
+		}
+		return nil
+	})
+	if parents > 0 {
+		s.BranchingFactor = float64(replies) / float64(parents)
+	}
+
+	return s
+}
@@ -0,0 +1,122 @@
+package hn_test
+
+import (
+	"fmt"
+	"testing"
+	"workshop-starter/pkg/hn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoryTree(t *testing.T) {
+	story := buildThreadStory(t)
+
+	t.Run("walk depth first", func(t *testing.T) {
+		var visited []string
+		err := story.Walk(func(c *hn.Comment, depth int) error {
+			visited = append(visited, fmt.Sprintf("%d@%d", c.Id, depth))
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"101@1", "103@2", "106@3", "104@2", "102@1", "105@2"}, visited)
+	})
+
+	t.Run("walk breadth first", func(t *testing.T) {
+		var visited []int
+		err := story.WalkBreadthFirst(func(c *hn.Comment, depth int) error {
+			visited = append(visited, c.Id)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{101, 102, 103, 104, 105, 106}, visited)
+	})
+
+	t.Run("skip subtree", func(t *testing.T) {
+		var visited []int
+		visit := func(c *hn.Comment, depth int) error {
+			visited = append(visited, c.Id)
+			if c.Id == 101 {
+				return hn.SkipSubtree
+			}
+			return nil
+		}
+
+		assert.NoError(t, story.Walk(visit))
+		assert.Equal(t, []int{101, 102, 105}, visited)
+
+		visited = nil
+		assert.NoError(t, story.WalkBreadthFirst(visit))
+		assert.Equal(t, []int{101, 102, 105}, visited)
+	})
+
+	t.Run("stop walk", func(t *testing.T) {
+		stop := fmt.Errorf("stop")
+		count := 0
+		err := story.Walk(func(c *hn.Comment, depth int) error {
+			count++
+			return stop
+		})
+
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("walk replies of a comment", func(t *testing.T) {
+		var visited []int
+		err := story.Comments[0].Walk(func(c *hn.Comment, depth int) error {
+			visited = append(visited, c.Id)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{103, 106, 104}, visited)
+	})
+
+	t.Run("flatten", func(t *testing.T) {
+		flat := story.Flatten()
+
+		var got []string
+		for _, f := range flat {
+			got = append(got, fmt.Sprintf("%d<%d@%d", f.Comment.Id, f.ParentID, f.Depth))
+		}
+		assert.Equal(t, []string{"101<100@1", "103<101@2", "106<103@3", "104<101@2", "102<100@1", "105<102@2"}, got)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		assert.Equal(t, "106", story.Find(106).Text)
+		assert.Nil(t, story.Find(1))
+		assert.Equal(t, 106, story.Comments[0].Find(106).Id)
+		assert.Nil(t, story.Comments[1].Find(106))
+
+		found := story.FindByAuthor("d")
+		assert.Len(t, found, 1)
+		assert.Equal(t, 104, found[0].Id)
+	})
+
+	t.Run("find returns a pointer into the story", func(t *testing.T) {
+		story := buildThreadStory(t)
+		story.Find(105).Text = "changed"
+
+		assert.Equal(t, "changed", story.Comments[1].ChildComments[0].Text)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats := story.Stats()
+
+		assert.Equal(t, 6, stats.Count)
+		assert.Equal(t, 3, stats.MaxDepth)
+		assert.Equal(t, 1.5, stats.BranchingFactor)
+		assert.Equal(t, 101, stats.MostRepliedID)
+		assert.Equal(t, 2, stats.MostReplies)
+		assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1, "f": 1}, stats.Authors)
+	})
+
+	t.Run("stats of an empty story", func(t *testing.T) {
+		stats := hn.Story{}.Stats()
+
+		assert.Equal(t, 0, stats.Count)
+		assert.Equal(t, 0.0, stats.BranchingFactor)
+	})
+}
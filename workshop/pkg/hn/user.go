@@ -0,0 +1,37 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ErrUserNotFound is returned by GetUser when the API has no such user.
+var ErrUserNotFound = errors.New("user not found")
+
+type User struct {
+	Id      string
+	Created int64
+	Karma   int
+	About   string
+	// Submitted lists the IDs of the user's stories, polls and comments.
+	Submitted []int
+}
+
+func (s *HackerNewsClient) GetUser(name string) (User, error) {
+	return s.GetUserContext(context.Background(), name)
+}
+
+func (s *HackerNewsClient) GetUserContext(ctx context.Context, name string) (User, error) {
+	var user User
+	if err := s.getJSON(ctx, "/user/"+url.PathEscape(name)+".json", &user); err != nil {
+		return User{}, err
+	}
+
+	// Like items, unknown users come back as a literal null.
+	if user.Id == "" {
+		return User{}, ErrUserNotFound
+	}
+
+	return user, nil
+}
@@ -0,0 +1,47 @@
+package hn_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"workshop-starter/pkg/hn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClient_GetUser(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/user/pg.json", r.URL.Path)
+			_, _ = w.Write([]byte(`{"id":"pg","created":1160418092,"karma":155111,"about":"Bug fixer.","submitted":[8863,121003]}`))
+		}))
+		defer ts.Close()
+
+		user, err := hn.NewHTTPClientFor(ts.URL).GetUser("pg")
+
+		assert.NoError(t, err)
+		assert.Equal(t, hn.User{Id: "pg", Created: 1160418092, Karma: 155111, About: "Bug fixer.", Submitted: []int{8863, 121003}}, user)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("null"))
+		}))
+		defer ts.Close()
+
+		_, err := hn.NewHTTPClientFor(ts.URL).GetUser("nobody")
+
+		assert.Equal(t, hn.ErrUserNotFound, err)
+	})
+
+	t.Run("500", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+
+		_, err := hn.NewHTTPClientFor(ts.URL).GetUser("pg")
+
+		assert.Equal(t, &hn.StatusError{StatusCode: 500}, err)
+	})
+}
@@ -0,0 +1,445 @@
+// Package server fronts the Hacker News API with a JSON API of its own. It
+// caches upstream answers, limits the rate of upstream requests and retries
+// the ones that fail transiently, so that several tools can share one well
+// behaved consumer of the API.
+//
+// Endpoints:
+//
+//	GET /items/{id}
+//	GET /stories/{id}?depth=N
+//	GET /lists/{top,new,best,ask,show,jobs}?page=N&per_page=N
+//	GET /users/{id}
+//
+// Responses carry an ETag and are gzipped for clients that accept it.
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"workshop-starter/pkg/hn"
+)
+
+// Upstream is the API a Server fronts. *hn.HackerNewsClient satisfies it.
+type Upstream interface {
+	hn.Client
+	GetStories(list hn.StoryList) ([]int, error)
+	GetUser(name string) (hn.User, error)
+}
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+	// maxComments bounds the comments of a story, deep or not.
+	maxComments = 1000
+	// fetchers is the number of items of a list page fetched in parallel.
+	fetchers = 8
+)
+
+// Server is an http.Handler serving the API.
+type Server struct {
+	upstream Upstream
+	cache    *cache
+	limiter  *limiter
+	ttl      time.Duration
+	attempts int
+	backoff  time.Duration
+	now      func() time.Time
+	sleep    func(time.Duration)
+	mux      *http.ServeMux
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithTTL sets how long upstream answers are cached. The default is one
+// minute.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.ttl = ttl
+	}
+}
+
+// WithRateLimit lets at most perSecond upstream requests through, in bursts
+// of up to burst. The default is 10 per second in bursts of 10; zero turns
+// the limit off.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(s *Server) {
+		if perSecond <= 0 {
+			s.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		s.limiter = &limiter{interval: time.Duration(float64(time.Second) / perSecond), burst: burst}
+	}
+}
+
+// WithRetries sets how many times an upstream request is tried and the wait
+// before the first retry, doubled for each further one. The default is 3
+// attempts, starting at 200ms.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(s *Server) {
+		if attempts < 1 {
+			attempts = 1
+		}
+		s.attempts, s.backoff = attempts, backoff
+	}
+}
+
+// WithClock replaces the clock and the sleep of the cache, the rate limit
+// and the retries. The sleep is not interrupted when a request goes away.
+func WithClock(now func() time.Time, sleep func(time.Duration)) Option {
+	return func(s *Server) {
+		s.now, s.sleep = now, sleep
+	}
+}
+
+// New returns a Server fronting upstream.
+func New(upstream Upstream, opts ...Option) *Server {
+	s := &Server{
+		upstream: upstream,
+		ttl:      time.Minute,
+		attempts: 3,
+		backoff:  200 * time.Millisecond,
+		now:      time.Now,
+	}
+	WithRateLimit(10, 10)(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.cache = newCache(s.now)
+	if s.limiter != nil {
+		s.limiter.now, s.limiter.pause = s.now, s.pause
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/items/", s.handle(s.getItem))
+	s.mux.HandleFunc("/stories/", s.handle(s.getStory))
+	s.mux.HandleFunc("/lists/", s.handle(s.getList))
+	s.mux.HandleFunc("/users/", s.handle(s.getUser))
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// httpError is an error with the status it is answered with.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// handle turns a function returning the value to encode into a handler. It
+// encodes the value as JSON, answers conditional requests from its ETag and
+// gzips it for clients that accept it.
+func (s *Server) handle(h func(w http.ResponseWriter, r *http.Request, arg string) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, &httpError{status: http.StatusMethodNotAllowed, message: "method not allowed"})
+			return
+		}
+		// The argument is what follows the prefix of the pattern.
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+		if len(parts) != 2 || parts[1] == "" || strings.Contains(parts[1], "/") {
+			writeError(w, &httpError{status: http.StatusNotFound, message: "not found"})
+			return
+		}
+
+		value, err := h(w, r, parts[1])
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		body, err := json.Marshal(value)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		body = append(body, '\n')
+
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		gzipped := acceptsGzip(r)
+		if gzipped {
+			// Representations get their own tags.
+			etag = strings.TrimSuffix(etag, `"`) + `-gzip"`
+		}
+		header := w.Header()
+		header.Set("Content-Type", "application/json; charset=utf-8")
+		header.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(s.ttl/time.Second)))
+		header.Set("ETag", etag)
+		header.Add("Vary", "Accept-Encoding")
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if gzipped {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write(body)
+			zw.Close()
+			body = buf.Bytes()
+			header.Set("Content-Encoding", "gzip")
+		}
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header lists gzip with a
+// quality above zero.
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(coding, ";")
+		if strings.TrimSpace(params[0]) != "gzip" {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		status = he.status
+	case notFound(err):
+		status = http.StatusNotFound
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// item returns an item from the cache or upstream.
+func (s *Server) item(ctx context.Context, id int) (hn.Item, error) {
+	v, err := s.cache.get(ctx, "item/"+strconv.Itoa(id), s.ttl, func(ctx context.Context) (interface{}, error) {
+		return s.call(ctx, func() (interface{}, error) { return s.upstream.GetItem(id) })
+	})
+	if err != nil {
+		return hn.Item{}, err
+	}
+
+	return v.(hn.Item), nil
+}
+
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request, arg string) (interface{}, error) {
+	id, err := itemID(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.item(r.Context(), id)
+}
+
+func (s *Server) getStory(w http.ResponseWriter, r *http.Request, arg string) (interface{}, error) {
+	id, err := itemID(arg)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := queryInt(r, "depth", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := hn.NewStoryBuilder(cachedClient{s, r.Context()}, hn.WithMaxDepth(depth), hn.WithMaxComments(maxComments))
+
+	return builder.BuildContext(r.Context(), id)
+}
+
+// listPage is a page of a story list.
+type listPage struct {
+	List    string    `json:"list"`
+	Page    int       `json:"page"`
+	PerPage int       `json:"per_page"`
+	Total   int       `json:"total"`
+	Items   []hn.Item `json:"items"`
+}
+
+func (s *Server) getList(w http.ResponseWriter, r *http.Request, arg string) (interface{}, error) {
+	list := hn.StoryList(arg)
+	if arg == "jobs" {
+		list = hn.JobStories
+	}
+	valid := false
+	for _, l := range hn.StoryLists {
+		valid = valid || l == list
+	}
+	if !valid {
+		return nil, &httpError{status: http.StatusNotFound, message: fmt.Sprintf("unknown list %q", arg)}
+	}
+	page, err := queryInt(r, "page", 1)
+	if err != nil || page < 1 {
+		return nil, badRequest("invalid page %q", r.URL.Query().Get("page"))
+	}
+	perPage, err := queryInt(r, "per_page", defaultPerPage)
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return nil, badRequest("per_page must be between 1 and %d", maxPerPage)
+	}
+
+	v, err := s.cache.get(r.Context(), "list/"+string(list), s.ttl, func(ctx context.Context) (interface{}, error) {
+		return s.call(ctx, func() (interface{}, error) { return s.upstream.GetStories(list) })
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := v.([]int)
+
+	from, to := (page-1)*perPage, page*perPage
+	if from > len(ids) {
+		from = len(ids)
+	}
+	if to > len(ids) {
+		to = len(ids)
+	}
+	items, err := s.items(r.Context(), ids[from:to])
+	if err != nil {
+		return nil, err
+	}
+	setLinks(w, r, page, perPage, len(ids))
+
+	return listPage{List: arg, Page: page, PerPage: perPage, Total: len(ids), Items: items}, nil
+}
+
+// items fetches items in parallel, keeping their order. Items that do not
+// exist are left out.
+func (s *Server) items(ctx context.Context, ids []int) ([]hn.Item, error) {
+	items := make([]hn.Item, len(ids))
+	errs := make([]error, len(ids))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for n := 0; n < fetchers && n < len(ids); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				items[i], errs[i] = s.item(ctx, ids[i])
+			}
+		}()
+	}
+	for i := range ids {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	found := make([]hn.Item, 0, len(items))
+	for i, item := range items {
+		if notFound(errs[i]) {
+			continue
+		}
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		found = append(found, item)
+	}
+
+	return found, nil
+}
+
+// setLinks sets the Link header to the neighbouring pages and the
+// X-Total-Count header to the size of the list.
+func setLinks(w http.ResponseWriter, r *http.Request, page, perPage, total int) {
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+	link := func(p int, rel string) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(p))
+		q.Set("per_page", strconv.Itoa(perPage))
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+	if page < last {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, arg string) (interface{}, error) {
+	v, err := s.cache.get(r.Context(), "user/"+arg, s.ttl, func(ctx context.Context) (interface{}, error) {
+		return s.call(ctx, func() (interface{}, error) { return s.upstream.GetUser(arg) })
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(hn.User), nil
+}
+
+func itemID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 {
+		return 0, badRequest("invalid item ID %q", s)
+	}
+
+	return id, nil
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, badRequest("invalid %s %q", name, s)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,405 @@
+package server_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// upstream is a fake API counting the requests it gets. failures makes the
+// first requests for a key fail with a 503, and items are held back until
+// gate is closed when there is one.
+type upstream struct {
+	gate     chan struct{}
+	mu       sync.Mutex
+	items    map[int]hn.Item
+	lists    map[hn.StoryList][]int
+	users    map[string]hn.User
+	failures map[string]int
+	calls    map[string]int
+}
+
+func newUpstream() *upstream {
+	return &upstream{
+		items: map[int]hn.Item{
+			1: {Id: 1, Type: "story", Author: "pg", Title: "Y Combinator", Score: 57, Kids: []int{2}, Descendants: 2},
+			2: {Id: 2, Type: "comment", Author: "sama", Parent: 1, Text: "Nice.", Kids: []int{3}},
+			3: {Id: 3, Type: "comment", Author: "pg", Parent: 2, Text: "Thanks."},
+			4: {Id: 4, Type: "story", Author: "sama", Title: "Startups", Score: 12},
+			5: {Id: 5, Type: "story", Author: "tel", Title: "Arc", Score: 3},
+		},
+		lists:    map[hn.StoryList][]int{hn.TopStories: {4, 1, 404, 5}},
+		users:    map[string]hn.User{"pg": {Id: "pg", Karma: 155111}},
+		failures: map[string]int{},
+		calls:    map[string]int{},
+	}
+}
+
+func (u *upstream) call(key string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.calls[key]++
+	if u.failures[key] > 0 {
+		u.failures[key]--
+		return &hn.StatusError{StatusCode: http.StatusServiceUnavailable}
+	}
+
+	return nil
+}
+
+func (u *upstream) count(key string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.calls[key]
+}
+
+func (u *upstream) MaxItem() (int, error) {
+	return 5, u.call("maxitem")
+}
+
+func (u *upstream) GetItem(id int) (hn.Item, error) {
+	if err := u.call("item/" + strconv.Itoa(id)); err != nil {
+		return hn.Item{}, err
+	}
+	if u.gate != nil {
+		<-u.gate
+	}
+	item, ok := u.items[id]
+	if !ok {
+		return hn.Item{}, hn.ErrItemNotFound
+	}
+
+	return item, nil
+}
+
+func (u *upstream) GetStories(list hn.StoryList) ([]int, error) {
+	if err := u.call("list/" + string(list)); err != nil {
+		return nil, err
+	}
+
+	return u.lists[list], nil
+}
+
+func (u *upstream) GetUser(name string) (hn.User, error) {
+	if err := u.call("user/" + name); err != nil {
+		return hn.User{}, err
+	}
+	user, ok := u.users[name]
+	if !ok {
+		return hn.User{}, hn.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// clock is a fake clock whose sleeps advance it.
+type clock struct {
+	mu     sync.Mutex
+	t      time.Time
+	sleeps []time.Duration
+}
+
+func newClock() *clock {
+	return &clock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.t
+}
+
+func (c *clock) sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.t = c.t.Add(d)
+}
+
+func newServer(up *upstream, c *clock, opts ...server.Option) *server.Server {
+	opts = append([]server.Option{server.WithClock(c.now, c.sleep), server.WithRateLimit(0, 0)}, opts...)
+
+	return server.New(up, opts...)
+}
+
+func get(t *testing.T, h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestServer_Items(t *testing.T) {
+	t.Run("cached", func(t *testing.T) {
+		up, c := newUpstream(), newClock()
+		s := newServer(up, c, server.WithTTL(time.Minute))
+
+		w := get(t, s, "/items/1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+		var item hn.Item
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		assert.Equal(t, up.items[1], item)
+
+		get(t, s, "/items/1")
+		assert.Equal(t, 1, up.count("item/1"))
+
+		c.sleep(time.Minute)
+		get(t, s, "/items/1")
+		assert.Equal(t, 2, up.count("item/1"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		up := newUpstream()
+		s := newServer(up, newClock())
+
+		w := get(t, s, "/items/404")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"item not found"}`, w.Body.String())
+		get(t, s, "/items/404")
+		assert.Equal(t, 1, up.count("item/404"), "not found is cached")
+
+		assert.Equal(t, http.StatusBadRequest, get(t, s, "/items/abc").Code)
+		assert.Equal(t, http.StatusNotFound, get(t, s, "/items/1/kids").Code)
+		assert.Equal(t, http.StatusNotFound, get(t, s, "/nothing").Code)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/1", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+	})
+}
+
+func TestServer_Stories(t *testing.T) {
+	up := newUpstream()
+	s := newServer(up, newClock())
+
+	w := get(t, s, "/stories/1?depth=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var story hn.Story
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &story))
+	assert.Equal(t, "Y Combinator", story.Title)
+	assert.Len(t, story.Comments, 1)
+	assert.Empty(t, story.Comments[0].ChildComments)
+	assert.Equal(t, []int{3}, story.Comments[0].More)
+
+	assert.NoError(t, json.Unmarshal(get(t, s, "/stories/1").Body.Bytes(), &story))
+	assert.Equal(t, "Thanks.", story.Comments[0].ChildComments[0].Text)
+	assert.Equal(t, 1, up.count("item/2"), "stories share the item cache")
+
+	assert.Equal(t, http.StatusBadRequest, get(t, s, "/stories/1?depth=x").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, s, "/stories/404").Code)
+}
+
+func TestServer_Lists(t *testing.T) {
+	up := newUpstream()
+	s := newServer(up, newClock())
+
+	var page struct {
+		List    string    `json:"list"`
+		Page    int       `json:"page"`
+		PerPage int       `json:"per_page"`
+		Total   int       `json:"total"`
+		Items   []hn.Item `json:"items"`
+	}
+	w := get(t, s, "/lists/top?per_page=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, "top", page.List)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 4, page.Total)
+	assert.Equal(t, []int{4, 1}, []int{page.Items[0].Id, page.Items[1].Id})
+	assert.Equal(t, "4", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</lists/top?page=1&per_page=2>; rel="first", </lists/top?page=2&per_page=2>; rel="next", </lists/top?page=2&per_page=2>; rel="last"`, w.Header().Get("Link"))
+
+	w = get(t, s, "/lists/top?page=2&per_page=2")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Items, 1, "missing items are left out")
+	assert.Equal(t, 5, page.Items[0].Id)
+	assert.Contains(t, w.Header().Get("Link"), `</lists/top?page=1&per_page=2>; rel="prev"`)
+
+	w = get(t, s, "/lists/top?page=9")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Empty(t, page.Items)
+	assert.Equal(t, 1, up.count("list/top"))
+
+	assert.Equal(t, http.StatusBadRequest, get(t, s, "/lists/top?per_page=1000").Code)
+	assert.Equal(t, http.StatusBadRequest, get(t, s, "/lists/top?page=0").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, s, "/lists/hot").Code)
+	assert.Equal(t, http.StatusOK, get(t, s, "/lists/jobs").Code)
+	assert.Equal(t, 1, up.count("list/job"))
+}
+
+func TestServer_Users(t *testing.T) {
+	s := newServer(newUpstream(), newClock())
+
+	w := get(t, s, "/users/pg")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var user hn.User
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, 155111, user.Karma)
+
+	assert.Equal(t, http.StatusNotFound, get(t, s, "/users/nobody").Code)
+}
+
+func TestServer_ETagAndGzip(t *testing.T) {
+	s := newServer(newUpstream(), newClock())
+
+	plain := get(t, s, "/items/1")
+	etag := plain.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "Accept-Encoding", plain.Header().Get("Vary"))
+
+	w := get(t, s, "/items/1", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, http.StatusOK, get(t, s, "/items/1", "If-None-Match", `"other"`).Code)
+
+	w = get(t, s, "/items/1", "Accept-Encoding", "deflate, gzip;q=0.8")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	zr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, plain.Body.String(), string(body))
+
+	assert.Empty(t, get(t, s, "/items/1", "Accept-Encoding", "gzip;q=0").Header().Get("Content-Encoding"))
+}
+
+func TestServer_Retries(t *testing.T) {
+	t.Run("transient failures", func(t *testing.T) {
+		up, c := newUpstream(), newClock()
+		up.failures["item/1"] = 2
+		s := newServer(up, c, server.WithRetries(3, 100*time.Millisecond))
+
+		assert.Equal(t, http.StatusOK, get(t, s, "/items/1").Code)
+		assert.Equal(t, 3, up.count("item/1"))
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, c.sleeps)
+	})
+
+	t.Run("giving up", func(t *testing.T) {
+		up := newUpstream()
+		up.failures["item/1"] = 2
+		s := newServer(up, newClock(), server.WithRetries(2, time.Millisecond))
+
+		w := get(t, s, "/items/1")
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.JSONEq(t, `{"error":"Non 200 Status Code: 503"}`, w.Body.String())
+		assert.Equal(t, 2, up.count("item/1"))
+
+		assert.Equal(t, http.StatusOK, get(t, s, "/items/1").Code, "errors are not cached")
+	})
+
+	t.Run("not found is not retried", func(t *testing.T) {
+		up := newUpstream()
+		s := newServer(up, newClock(), server.WithRetries(3, time.Millisecond))
+
+		get(t, s, "/users/nobody")
+		assert.Equal(t, 1, up.count("user/nobody"))
+	})
+}
+
+func TestServer_Coalescing(t *testing.T) {
+	up := newUpstream()
+	up.gate = make(chan struct{})
+	s := newServer(up, newClock())
+
+	codes := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = get(t, s, "/items/1").Code
+		}(i)
+	}
+	for up.count("item/1") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// Give the other requests time to find the fetch in progress.
+	time.Sleep(20 * time.Millisecond)
+	close(up.gate)
+	wg.Wait()
+
+	assert.Equal(t, []int{200, 200, 200, 200, 200}, codes)
+	assert.Equal(t, 1, up.count("item/1"))
+}
+
+func TestServer_CoalescingTimeout(t *testing.T) {
+	up := newUpstream()
+	s := server.New(up, server.WithRateLimit(5, 1))
+	assert.Equal(t, http.StatusOK, get(t, s, "/items/1").Code)
+
+	// The first request gives up waiting for the rate limit; the one that
+	// joined it fetches on its own.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	first := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/4", nil).WithContext(ctx))
+		first <- w.Code
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, get(t, s, "/items/4").Code)
+	assert.NotEqual(t, http.StatusOK, <-first)
+	assert.Equal(t, 1, up.count("item/4"))
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	up, c := newUpstream(), newClock()
+	s := newServer(up, c, server.WithRateLimit(1, 2))
+
+	for id := 1; id <= 4; id++ {
+		assert.Equal(t, http.StatusOK, get(t, s, "/items/"+strconv.Itoa(id)).Code)
+	}
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, c.sleeps)
+
+	// Cached answers do not count.
+	get(t, s, "/items/1")
+	assert.Len(t, c.sleeps, 2)
+}
+
+func TestServer_RateLimitCancel(t *testing.T) {
+	up := newUpstream()
+	s := server.New(up, server.WithRateLimit(0.001, 1))
+	assert.Equal(t, http.StatusOK, get(t, s, "/items/1").Code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/items/4", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.ServeHTTP(httptest.NewRecorder(), r)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request still waits for the rate limit")
+	}
+	assert.Equal(t, 0, up.count("item/4"))
+}
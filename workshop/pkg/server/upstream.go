@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"workshop-starter/pkg/hn"
+)
+
+// maxCacheEntries bounds the cache. When it is full, expired entries are
+// dropped, and everything when none has expired.
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+// flight is an upstream fetch in progress, which requests for the same key
+// wait for instead of fetching again.
+type flight struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// cache keeps upstream answers, and not found errors, for a while.
+type cache struct {
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*flight
+	now      func() time.Time
+}
+
+func newCache(now func() time.Time) *cache {
+	return &cache{entries: map[string]cacheEntry{}, inflight: map[string]*flight{}, now: now}
+}
+
+// get returns the cached answer for key or calls fetch and caches its
+// answer for ttl. Only not found errors are cached. Concurrent misses on a
+// key share one fetch; when the request that started it is cancelled or
+// times out, the others start over.
+func (c *cache) get(ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	for {
+		c.mu.Lock()
+		if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+			c.mu.Unlock()
+			return e.value, e.err
+		}
+		if f := c.inflight[key]; f != nil {
+			c.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if (errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+				continue
+			}
+			return f.value, f.err
+		}
+		f := &flight{done: make(chan struct{})}
+		c.inflight[key] = f
+		c.mu.Unlock()
+
+		f.value, f.err = fetch(ctx)
+
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if f.err == nil || notFound(f.err) {
+			if len(c.entries) >= maxCacheEntries {
+				c.evict()
+			}
+			c.entries[key] = cacheEntry{value: f.value, err: f.err, expires: c.now().Add(ttl)}
+		}
+		c.mu.Unlock()
+		close(f.done)
+
+		return f.value, f.err
+	}
+}
+
+func (c *cache) evict() {
+	now := c.now()
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) >= maxCacheEntries {
+		c.entries = map[string]cacheEntry{}
+	}
+}
+
+// limiter is a token bucket: it lets burst requests through at once and
+// then one every interval.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	next     time.Time
+	now      func() time.Time
+	pause    func(ctx context.Context, d time.Duration) error
+}
+
+// wait blocks until a request may be sent or ctx is done. A nil limiter
+// never blocks.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := l.now()
+	// Unused tokens pile up to burst.
+	if earliest := now.Add(-time.Duration(l.burst-1) * l.interval); l.next.Before(earliest) {
+		l.next = earliest
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		return l.pause(ctx, d)
+	}
+
+	return ctx.Err()
+}
+
+// call sends one upstream request through the limiter, retrying the
+// failures that may be transient with exponential backoff. It gives up
+// waiting when ctx is done.
+func (s *Server) call(ctx context.Context, fetch func() (interface{}, error)) (interface{}, error) {
+	for attempt := 1; ; attempt++ {
+		if err := s.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		value, err := fetch()
+		if err == nil || !retryable(err) || attempt >= s.attempts {
+			return value, err
+		}
+		if err := s.pause(ctx, s.backoff<<uint(attempt-1)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pause waits for d or until ctx is done. The sleep of WithClock, when
+// given, is not interrupted.
+func (s *Server) pause(ctx context.Context, d time.Duration) error {
+	if s.sleep != nil {
+		s.sleep(d)
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryable reports whether an upstream error may go away on its own:
+// network errors, throttling and server errors.
+func retryable(err error) bool {
+	var status *hn.StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= 500
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+
+	return errors.As(err, &netErr) || errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func notFound(err error) bool {
+	var status *hn.StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusNotFound
+	}
+
+	return errors.Is(err, hn.ErrItemNotFound) || errors.Is(err, hn.ErrUserNotFound)
+}
+
+// cachedClient is the hn.Client the StoryBuilder of a Server uses, so that
+// story trees share the item cache and stop with their request.
+type cachedClient struct {
+	s   *Server
+	ctx context.Context
+}
+
+func (c cachedClient) MaxItem() (int, error) {
+	id, err := c.s.call(c.ctx, func() (interface{}, error) { return c.s.upstream.MaxItem() })
+	if err != nil {
+		return 0, err
+	}
+
+	return id.(int), nil
+}
+
+func (c cachedClient) GetItem(id int) (hn.Item, error) {
+	return c.s.item(c.ctx, id)
+}
+
+func (c cachedClient) GetItemContext(ctx context.Context, id int) (hn.Item, error) {
+	return c.s.item(ctx, id)
+}
@@ -0,0 +1,294 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/markup"
+)
+
+// snippetLength is the number of runes of a comment shown on user pages.
+const snippetLength = 160
+
+// The pages live in three directories below the index, so every link from
+// a page to another starts with "../".
+func storyPath(id int) string     { return fmt.Sprintf("items/%d.html", id) }
+func dayPath(date string) string  { return "days/" + date + ".html" }
+func userPath(name string) string { return "users/" + url.PathEscape(name) + ".html" }
+
+var storyRenderer = hn.HTMLRenderer{
+	UserURL: func(name string) string { return "../" + userPath(name) },
+	Home:    "../index.html",
+}
+
+// storyRow is a story in the lists of day and user pages.
+type storyRow struct {
+	Id       int
+	Title    string
+	Url      string
+	Domain   string
+	Author   string
+	UserPage string
+	Page     string
+	Score    int
+	Comments int
+	Posted   string
+}
+
+func newStoryRow(item hn.Item) storyRow {
+	return storyRow{
+		Id:       item.Id,
+		Title:    item.Title,
+		Url:      item.Url,
+		Domain:   hn.Domain(item.Url),
+		Author:   item.Author,
+		UserPage: "../" + userPath(item.Author),
+		Page:     "../" + storyPath(item.Id),
+		Score:    item.Score,
+		Comments: item.Descendants,
+		Posted:   time.Unix(item.Time, 0).UTC().Format("2006-01-02 15:04 UTC"),
+	}
+}
+
+type dayPage struct {
+	Date    string
+	Page    string
+	Older   string
+	Newer   string
+	Stories []storyRow
+}
+
+// dayPages groups stories by the UTC day they were posted, newest day
+// first, with the best stories of a day first.
+func dayPages(stories []hn.Item) []dayPage {
+	byDay := map[string][]hn.Item{}
+	for _, item := range stories {
+		date := day(item.Time)
+		byDay[date] = append(byDay[date], item)
+	}
+	var dates []string
+	for date := range byDay {
+		dates = append(dates, date)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+	pages := make([]dayPage, len(dates))
+	for i, date := range dates {
+		items := byDay[date]
+		sort.Slice(items, func(a, b int) bool {
+			if items[a].Score != items[b].Score {
+				return items[a].Score > items[b].Score
+			}
+			return items[a].Id < items[b].Id
+		})
+		pages[i] = dayPage{Date: date, Page: dayPath(date)}
+		for _, item := range items {
+			pages[i].Stories = append(pages[i].Stories, newStoryRow(item))
+		}
+		if i > 0 {
+			pages[i].Newer = "../" + dayPath(dates[i-1])
+		}
+		if i+1 < len(dates) {
+			pages[i].Older = "../" + dayPath(dates[i+1])
+		}
+	}
+
+	return pages
+}
+
+type indexPage struct {
+	Days    []dayPage
+	Stories int
+	Users   int
+}
+
+// commentRow is a comment on a user page.
+type commentRow struct {
+	Id      int
+	Page    string
+	On      string
+	Snippet string
+	Posted  string
+}
+
+type userPage struct {
+	Name     string
+	Stories  []storyRow
+	Comments []commentRow
+}
+
+// userPages lists the stories and visible comments of every author, newest
+// first. Comments link into the page of their story when it is mirrored.
+func userPages(items []hn.Item) []userPage {
+	byID := make(map[int]hn.Item, len(items))
+	for _, item := range items {
+		byID[item.Id] = item
+	}
+
+	users := map[string]*userPage{}
+	user := func(name string) *userPage {
+		if users[name] == nil {
+			users[name] = &userPage{Name: name}
+		}
+		return users[name]
+	}
+	sorted := append([]hn.Item(nil), items...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Time > sorted[b].Time })
+	for _, item := range sorted {
+		switch {
+		case item.Author == "":
+		case isStory(item):
+			u := user(item.Author)
+			u.Stories = append(u.Stories, newStoryRow(item))
+		case item.Type == "comment" && !item.Deleted && !item.Dead:
+			row := commentRow{
+				Id:      item.Id,
+				Page:    fmt.Sprintf("https://news.ycombinator.com/item?id=%d", item.Id),
+				Snippet: hn.Truncate(snippetLength, hn.InlineText(item.Text)),
+				Posted:  time.Unix(item.Time, 0).UTC().Format("2006-01-02 15:04 UTC"),
+			}
+			if root, ok := rootStory(byID, item); ok {
+				row.Page = fmt.Sprintf("../%s#c%d", storyPath(root.Id), item.Id)
+				row.On = root.Title
+			}
+			u := user(item.Author)
+			u.Comments = append(u.Comments, row)
+		}
+	}
+
+	var names []string
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pages := make([]userPage, len(names))
+	for i, name := range names {
+		pages[i] = *users[name]
+	}
+
+	return pages
+}
+
+// rootStory follows the parents of a comment up to its story.
+func rootStory(byID map[int]hn.Item, item hn.Item) (hn.Item, bool) {
+	for depth := 0; item.Parent != 0 && depth < 1000; depth++ {
+		parent, ok := byID[item.Parent]
+		if !ok {
+			return hn.Item{}, false
+		}
+		item = parent
+	}
+
+	return item, item.Parent == 0 && isStory(item)
+}
+
+// searchEntry is a story in search.json.
+type searchEntry struct {
+	Id       int    `json:"id"`
+	Title    string `json:"title"`
+	Url      string `json:"url,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Author   string `json:"by"`
+	Time     int64  `json:"time"`
+	Score    int    `json:"score"`
+	Comments int    `json:"comments"`
+	Page     string `json:"page"`
+}
+
+// searchIndex lists the stories by ID, with the plain text of their body.
+func searchIndex(stories []hn.Item) []searchEntry {
+	entries := make([]searchEntry, 0, len(stories))
+	for _, item := range stories {
+		entries = append(entries, searchEntry{
+			Id:       item.Id,
+			Title:    item.Title,
+			Url:      item.Url,
+			Text:     markup.RenderText(markup.Parse(item.Text), 0),
+			Author:   item.Author,
+			Time:     item.Time,
+			Score:    item.Score,
+			Comments: item.Descendants,
+			Page:     storyPath(item.Id),
+		})
+	}
+
+	return entries
+}
+
+func templatePage(t *template.Template, data interface{}) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		return t.Execute(w, data)
+	}
+}
+
+const layout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{template "title" .}}</title>
+<style>
+body { margin: 0 auto; max-width: 50em; padding: 1em; font: 15px/1.4 Verdana, Geneva, sans-serif; color: #222; background: #f6f6ef; }
+a { color: inherit; }
+nav { font-size: .85em; margin-bottom: 1em; }
+h1 { font-size: 1.4em; margin: 0 0 .5em; }
+h2 { font-size: 1.1em; }
+ol, ul { padding-left: 2em; }
+li { margin: .4em 0; }
+.meta { color: #828282; font-size: .85em; }
+</style>
+</head>
+<body>
+{{template "body" .}}
+</body>
+</html>
+{{define "story"}}<a href="{{.Page}}">{{.Title}}</a>{{with .Domain}} <span class="meta">({{.}})</span>{{end}}
+<div class="meta">{{.Score}} points by <a href="{{.UserPage}}">{{.Author}}</a> {{.Posted}} | <a href="{{.Page}}">{{plural .Comments "comment" "comments"}}</a>{{with .Url}} | <a href="{{.}}">link</a>{{end}}</div>{{end}}`
+
+func page(body string) *template.Template {
+	t := template.New("page").Funcs(template.FuncMap{"plural": hn.Plural})
+
+	return template.Must(template.Must(t.Parse(layout)).Parse(body))
+}
+
+var indexTemplate = page(`{{define "title"}}Hacker News mirror{{end}}
+{{define "body"}}<h1>Hacker News mirror</h1>
+<p class="meta">{{plural .Stories "story" "stories"}} by {{plural .Users "user" "users"}}. The stories are also listed in <a href="search.json">search.json</a>.</p>
+<ul>
+{{- range .Days}}
+<li><a href="{{.Page}}">{{.Date}}</a> <span class="meta">({{plural (len .Stories) "story" "stories"}})</span></li>
+{{- end}}
+</ul>{{end}}`)
+
+var dayTemplate = page(`{{define "title"}}Hacker News {{.Date}}{{end}}
+{{define "body"}}<nav><a href="../index.html">Home</a>{{with .Newer}} | <a href="{{.}}">newer</a>{{end}}{{with .Older}} | <a href="{{.}}">older</a>{{end}}</nav>
+<h1>{{.Date}}</h1>
+<ol>
+{{- range .Stories}}
+<li>{{template "story" .}}</li>
+{{- end}}
+</ol>{{end}}`)
+
+var userTemplate = page(`{{define "title"}}{{.Name}}{{end}}
+{{define "body"}}<nav><a href="../index.html">Home</a> | <a href="https://news.ycombinator.com/user?id={{.Name}}">profile</a></nav>
+<h1>{{.Name}}</h1>
+{{- with .Stories}}
+<h2>Stories</h2>
+<ol>
+{{- range .}}
+<li>{{template "story" .}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- with .Comments}}
+<h2>Comments</h2>
+<ul>
+{{- range .}}
+<li><div class="meta"><a href="{{.Page}}">{{.Posted}}</a>{{with .On}} on {{.}}{{end}}</div>{{.Snippet}}</li>
+{{- end}}
+</ul>
+{{- end}}{{end}}`)
@@ -0,0 +1,298 @@
+// Package site generates a static, browsable mirror of Hacker News from
+// stored items: a front page per day, a page per story with its thread, a
+// page per user listing their submissions and a JSON search index.
+//
+// Regeneration is incremental. Every page is fingerprinted by the data it
+// shows and only the pages whose fingerprint changed are written again.
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"workshop-starter/pkg/hn"
+)
+
+// ManifestFile is the file, in the output directory, that holds the
+// fingerprints of the pages of the last run.
+const ManifestFile = "manifest.json"
+
+// version is part of every fingerprint. Bump it when the pages change so
+// that the next run rewrites them all.
+const version = "3"
+
+// Store is where the items come from. *hn.FileStore satisfies it.
+type Store interface {
+	hn.Client
+	IDs() ([]int, error)
+}
+
+// Generator writes the site for a Store into Dir.
+type Generator struct {
+	Store   Store
+	Dir     string
+	builder *hn.StoryBuilder
+}
+
+// Report counts what a run did to the pages.
+type Report struct {
+	Written   int
+	Unchanged int
+	Removed   int
+}
+
+// New returns a Generator building the stories of store with a
+// StoryBuilder.
+func New(store Store, dir string) *Generator {
+	return &Generator{Store: store, Dir: dir, builder: hn.NewStoryBuilder(store)}
+}
+
+// run holds the state of one Generate call.
+type run struct {
+	dir    string
+	old    map[string]string
+	pages  map[string]string
+	report Report
+}
+
+// Generate writes the pages whose data changed since the last run and
+// removes the pages that are gone, such as the pages of deleted stories.
+func (g *Generator) Generate() (Report, error) {
+	items, err := g.load()
+	if err != nil {
+		return Report{}, err
+	}
+	r := &run{dir: g.Dir, old: map[string]string{}, pages: map[string]string{}}
+	if err := r.loadManifest(); err != nil {
+		return Report{}, err
+	}
+
+	var stories []hn.Item
+	byID := make(map[int]hn.Item, len(items))
+	for _, item := range items {
+		byID[item.Id] = item
+		if isStory(item) {
+			stories = append(stories, item)
+		}
+	}
+
+	// Story pages are fingerprinted by their stored items, so that only the
+	// changed threads are built.
+	for _, item := range stories {
+		id := item.Id
+		err := r.page(storyPath(id), thread(byID, item), func(w io.Writer) error {
+			s, err := g.builder.Build(id)
+			if err != nil {
+				return err
+			}
+			return storyRenderer.Render(w, s)
+		})
+		if err != nil {
+			return r.report, err
+		}
+	}
+
+	days := dayPages(stories)
+	for _, d := range days {
+		if err := r.page(dayPath(d.Date), d, templatePage(dayTemplate, d)); err != nil {
+			return r.report, err
+		}
+	}
+	index := indexPage{Days: days, Stories: len(stories)}
+	for _, user := range userPages(items) {
+		if err := r.page(userPath(user.Name), user, templatePage(userTemplate, user)); err != nil {
+			return r.report, err
+		}
+		index.Users++
+	}
+	if err := r.page("index.html", index, templatePage(indexTemplate, index)); err != nil {
+		return r.report, err
+	}
+	entries := searchIndex(stories)
+	err = r.page("search.json", entries, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(entries)
+	})
+	if err != nil {
+		return r.report, err
+	}
+
+	if err := r.removeStale(); err != nil {
+		return r.report, err
+	}
+
+	return r.report, r.saveManifest()
+}
+
+// load reads every stored item, sorted by ID.
+func (g *Generator) load() ([]hn.Item, error) {
+	ids, err := g.Store.IDs()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]hn.Item, 0, len(ids))
+	for _, id := range ids {
+		item, err := g.Store.GetItem(id)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// thread returns the stored items the page of a story shows: the story, its
+// poll options and its comments.
+func thread(byID map[int]hn.Item, story hn.Item) []hn.Item {
+	items := []hn.Item{story}
+	seen := map[int]bool{story.Id: true}
+	ids := append(append([]int(nil), story.Parts...), story.Kids...)
+	for len(ids) > 0 {
+		id := ids[0]
+		ids = ids[1:]
+		item, ok := byID[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		items = append(items, item)
+		ids = append(ids, item.Kids...)
+	}
+
+	return items
+}
+
+// isStory reports whether an item gets a page of its own.
+func isStory(item hn.Item) bool {
+	switch item.Type {
+	case "story", "poll", "job":
+		return !item.Deleted && !item.Dead
+	}
+
+	return false
+}
+
+// page writes the page at path, relative to the output directory, unless
+// data has the fingerprint it had last time and the file is still there.
+func (r *run) page(path string, data interface{}, render func(w io.Writer) error) error {
+	fingerprint, err := fingerprint(path, data)
+	if err != nil {
+		return err
+	}
+	r.pages[path] = fingerprint
+	file := filepath.Join(r.dir, filepath.FromSlash(path))
+	if r.old[path] == fingerprint {
+		if _, err := os.Stat(file); err == nil {
+			r.report.Unchanged++
+			return nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+	if err := writeFile(file, buf.Bytes()); err != nil {
+		return err
+	}
+	r.report.Written++
+
+	return nil
+}
+
+func fingerprint(path string, data interface{}) (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	io.WriteString(h, version+"\x00"+path+"\x00")
+	h.Write(encoded)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// removeStale removes the pages of the last run that this run did not
+// produce.
+func (r *run) removeStale() error {
+	var stale []string
+	for path := range r.old {
+		if _, ok := r.pages[path]; !ok {
+			stale = append(stale, path)
+		}
+	}
+	sort.Strings(stale)
+	for _, path := range stale {
+		err := os.Remove(filepath.Join(r.dir, filepath.FromSlash(path)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		r.report.Removed++
+	}
+
+	return nil
+}
+
+func (r *run) loadManifest() error {
+	data, err := ioutil.ReadFile(filepath.Join(r.dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &r.old)
+}
+
+func (r *run) saveManifest() error {
+	data, err := json.MarshalIndent(r.pages, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(r.dir, ManifestFile), append(data, '\n'))
+}
+
+// writeFile replaces a file atomically, creating its directory.
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".page-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// day returns the UTC date of a Unix time.
+func day(t int64) string {
+	return time.Unix(t, 0).UTC().Format("2006-01-02")
+}
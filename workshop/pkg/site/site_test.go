@@ -0,0 +1,165 @@
+package site_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/site"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var items = []hn.Item{
+	{Id: 1, Type: "story", Author: "pg", Title: "Y Combinator", Url: "http://ycombinator.com", Score: 57, Time: 1160418111, Descendants: 2, Kids: []int{15}},
+	{Id: 15, Type: "comment", Author: "sama", Parent: 1, Time: 1160423461, Text: "&#34;the rising star of venture capital&#34; -unknown VC", Kids: []int{17}},
+	{Id: 17, Type: "comment", Author: "pg", Parent: 15, Time: 1160423565, Text: "Is there anywhere to eat on Sandhill Road?"},
+	{Id: 20, Type: "story", Author: "pg", Title: "Ask HN: The Arc Effect", Text: "Is it <i>really</i> worth it?", Score: 25, Time: 1160425000},
+	{Id: 8863, Type: "story", Author: "dhouston", Title: "My YC app: Dropbox", Url: "http://www.getdropbox.com/u/2/screencast.html", Score: 104, Time: 1175714200},
+}
+
+func newStore(t *testing.T) *hn.FileStore {
+	t.Helper()
+	store, err := hn.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+	for _, item := range items {
+		_, err := store.Put(item)
+		assert.NoError(t, err)
+	}
+
+	return store
+}
+
+func read(t *testing.T, dir, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	assert.NoError(t, err)
+
+	return string(data)
+}
+
+func TestGenerator(t *testing.T) {
+	pages := []string{
+		"index.html", "search.json",
+		"days/2006-10-09.html", "days/2007-04-04.html",
+		"items/1.html", "items/20.html", "items/8863.html",
+		"users/dhouston.html", "users/pg.html", "users/sama.html",
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		dir := t.TempDir()
+		report, err := site.New(newStore(t), dir).Generate()
+		assert.NoError(t, err)
+		assert.Equal(t, site.Report{Written: len(pages)}, report)
+
+		for _, page := range pages {
+			assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(page)))
+		}
+
+		index := read(t, dir, "index.html")
+		assert.Contains(t, index, `<a href="days/2007-04-04.html">2007-04-04</a> <span class="meta">(1 story)</span>`)
+		assert.Contains(t, index, "3 stories by 3 users")
+
+		day := read(t, dir, "days/2006-10-09.html")
+		assert.Contains(t, day, `<li><a href="../items/1.html">Y Combinator</a> <span class="meta">(ycombinator.com)</span>`)
+		assert.Contains(t, day, `<a href="../users/pg.html">pg</a>`)
+		assert.Contains(t, day, `<a href="../days/2007-04-04.html">newer</a>`)
+		assert.True(t, strings.Index(day, "items/1.html") < strings.Index(day, "items/20.html"), "best story first")
+
+		story := read(t, dir, "items/1.html")
+		assert.Contains(t, story, `<a href="../index.html">Home</a>`)
+		assert.Contains(t, story, `<a class="author" href="../users/sama.html">sama</a>`)
+		assert.Contains(t, story, `<details class="comment" id="c17" open>`)
+
+		user := read(t, dir, "users/pg.html")
+		assert.Contains(t, user, `<a href="../items/1.html#c17">2006-10-09 19:52 UTC</a> on Y Combinator</div>Is there anywhere to eat on Sandhill Road?`)
+		assert.True(t, strings.Index(user, "items/20.html") < strings.Index(user, "items/1.html\""), "newest story first")
+
+		var search []map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(read(t, dir, "search.json")), &search))
+		assert.Len(t, search, 3)
+		assert.Equal(t, "Ask HN: The Arc Effect", search[1]["title"])
+		assert.Equal(t, "Is it really worth it?", search[1]["text"])
+		assert.Equal(t, "items/20.html", search[1]["page"])
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		dir := t.TempDir()
+		store := newStore(t)
+		_, err := site.New(store, dir).Generate()
+		assert.NoError(t, err)
+
+		report, err := site.New(store, dir).Generate()
+		assert.NoError(t, err)
+		assert.Equal(t, site.Report{Unchanged: len(pages)}, report)
+
+		edited := items[2]
+		edited.Text = "Found one."
+		_, err = store.Put(edited)
+		assert.NoError(t, err)
+		report, err = site.New(store, dir).Generate()
+		assert.NoError(t, err)
+		assert.Equal(t, site.Report{Written: 2, Unchanged: len(pages) - 2}, report)
+		assert.Contains(t, read(t, dir, "items/1.html"), "Found one.")
+		assert.Contains(t, read(t, dir, "users/pg.html"), "Found one.")
+
+		assert.NoError(t, os.Remove(filepath.Join(dir, "items", "8863.html")))
+		report, err = site.New(store, dir).Generate()
+		assert.NoError(t, err)
+		assert.Equal(t, site.Report{Written: 1, Unchanged: len(pages) - 1}, report)
+	})
+
+	t.Run("unchanged stories are not built", func(t *testing.T) {
+		dir := t.TempDir()
+		store := &countingStore{FileStore: newStore(t)}
+		_, err := site.New(store, dir).Generate()
+		assert.NoError(t, err)
+
+		store.gets = 0
+		report, err := site.New(store, dir).Generate()
+		assert.NoError(t, err)
+		assert.Equal(t, site.Report{Unchanged: len(pages)}, report)
+		assert.Equal(t, len(items), store.gets)
+	})
+
+	t.Run("removed story", func(t *testing.T) {
+		dir := t.TempDir()
+		store := newStore(t)
+		_, err := site.New(store, dir).Generate()
+		assert.NoError(t, err)
+
+		dead := items[4]
+		dead.Dead = true
+		_, err = store.Put(dead)
+		assert.NoError(t, err)
+		report, err := site.New(store, dir).Generate()
+		assert.NoError(t, err)
+
+		assert.Equal(t, 3, report.Removed)
+		assert.False(t, exists(filepath.Join(dir, "items", "8863.html")))
+		assert.False(t, exists(filepath.Join(dir, "days", "2007-04-04.html")))
+		assert.False(t, exists(filepath.Join(dir, "users", "dhouston.html")))
+		assert.NotContains(t, read(t, dir, "days/2006-10-09.html"), "newer")
+	})
+}
+
+// countingStore counts the items read from a FileStore.
+type countingStore struct {
+	*hn.FileStore
+	gets int
+}
+
+func (s *countingStore) GetItem(id int) (hn.Item, error) {
+	s.gets++
+
+	return s.FileStore.GetItem(id)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
@@ -0,0 +1,436 @@
+// Package tui is a full-screen terminal browser for Hacker News. The App is
+// a state machine fed with keys that renders to plain text, so it runs the
+// same against a terminal and in tests.
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/markup"
+)
+
+// Client is what the App needs from the API: items and the story lists.
+// *hn.HackerNewsClient satisfies it.
+type Client interface {
+	hn.Client
+	GetStories(list hn.StoryList) ([]int, error)
+}
+
+// Lists are the story lists the App switches between with tab.
+var Lists = []hn.StoryList{hn.TopStories, hn.NewStories, hn.BestStories}
+
+// App is the state of the browser.
+type App struct {
+	client  Client
+	builder *hn.StoryBuilder
+	open    func(url string) error
+	width   int
+	height  int
+
+	list  int
+	ids   []int
+	items map[int]hn.Item
+	// cursor and offset are the selected and first visible rows of the list.
+	cursor int
+	offset int
+
+	// story is set while a story is open.
+	story *storyView
+
+	searching bool
+	query     string
+	status    string
+	done      bool
+}
+
+// Option configures an App.
+type Option func(*App)
+
+// WithOpener sets how URLs are opened. The default is OpenCommand("").
+func WithOpener(open func(url string) error) Option {
+	return func(a *App) {
+		a.open = open
+	}
+}
+
+// WithSize sets the screen size. The default is 80x24.
+func WithSize(width, height int) Option {
+	return func(a *App) {
+		a.Resize(width, height)
+	}
+}
+
+// OpenCommand opens URLs with the given command, or when name is empty with
+// $BROWSER or the opener of the platform. The command may carry arguments,
+// as in "firefox --new-window"; the URL is added last.
+func OpenCommand(name string) func(url string) error {
+	if name == "" {
+		name = os.Getenv("BROWSER")
+	}
+	if name == "" {
+		name = "xdg-open"
+		if runtime.GOOS == "darwin" {
+			name = "open"
+		}
+	}
+	args := strings.Fields(name)
+
+	return func(url string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("no command to open %s with", url)
+		}
+		cmd := exec.Command(args[0], append(args[1:len(args):len(args)], url)...)
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		// Reap the opener once it exits.
+		go cmd.Wait()
+
+		return nil
+	}
+}
+
+// New returns an App showing the first of Lists. Comment trees are loaded
+// one level at a time, as their replies get expanded.
+func New(client Client, opts ...Option) *App {
+	a := &App{
+		client:  client,
+		builder: hn.NewStoryBuilder(client, hn.WithMaxDepth(1)),
+		open:    OpenCommand(""),
+		width:   80,
+		height:  24,
+		items:   map[int]hn.Item{},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.load()
+
+	return a
+}
+
+// Resize changes the screen size.
+func (a *App) Resize(width, height int) {
+	if width < 20 {
+		width = 20
+	}
+	if height < 5 {
+		height = 5
+	}
+	a.width, a.height = width, height
+}
+
+// Done reports whether the user quit.
+func (a *App) Done() bool {
+	return a.done
+}
+
+// Run renders the App to out and feeds it keys read from in until the user
+// quits or in is exhausted. in is expected to be a terminal in raw mode.
+func (a *App) Run(in io.Reader, out io.Writer) error {
+	keys := bufio.NewReader(in)
+	for !a.done {
+		screen := "\x1b[H\x1b[2J" + strings.Replace(a.View(), "\n", "\r\n", -1)
+		if _, err := io.WriteString(out, screen); err != nil {
+			return err
+		}
+		key, err := ReadKey(keys)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a.HandleKey(key)
+	}
+
+	return nil
+}
+
+// load fetches the IDs of the current list.
+func (a *App) load() {
+	a.cursor, a.offset = 0, 0
+	ids, err := a.client.GetStories(Lists[a.list])
+	if err != nil {
+		a.ids = nil
+		a.status = fmt.Sprintf("error: %v", err)
+		return
+	}
+	a.ids = ids
+	a.status = ""
+}
+
+// item returns a story of the list, fetching it on first use.
+func (a *App) item(id int) (hn.Item, error) {
+	if item, ok := a.items[id]; ok {
+		return item, nil
+	}
+	item, err := a.client.GetItem(id)
+	if err != nil {
+		return item, err
+	}
+	a.items[id] = item
+
+	return item, nil
+}
+
+// HandleKey updates the App for one key press.
+func (a *App) HandleKey(k Key) {
+	if k == KeyCtrlC {
+		a.done = true
+		return
+	}
+	if a.searching {
+		a.editSearch(k)
+		return
+	}
+
+	a.status = ""
+	switch k {
+	case "/":
+		a.searching, a.query = true, ""
+		return
+	case "n":
+		a.search(a.query)
+		return
+	}
+	if a.story != nil {
+		a.storyKey(k)
+		return
+	}
+	a.listKey(k)
+}
+
+func (a *App) listKey(k Key) {
+	switch k {
+	case "q", KeyEsc:
+		a.done = true
+	case "j", KeyDown:
+		a.cursor = clamp(a.cursor+1, len(a.ids))
+	case "k", KeyUp:
+		a.cursor = clamp(a.cursor-1, len(a.ids))
+	case KeyPageDown, " ":
+		a.cursor = clamp(a.cursor+a.bodyHeight(), len(a.ids))
+	case KeyPageUp:
+		a.cursor = clamp(a.cursor-a.bodyHeight(), len(a.ids))
+	case "g", KeyHome:
+		a.cursor = 0
+	case "G", KeyEnd:
+		a.cursor = clamp(len(a.ids)-1, len(a.ids))
+	case KeyTab, "l", KeyRight:
+		a.list = (a.list + 1) % len(Lists)
+		a.load()
+	case "h", KeyLeft:
+		a.list = (a.list + len(Lists) - 1) % len(Lists)
+		a.load()
+	case "r":
+		a.items = map[int]hn.Item{}
+		a.load()
+	case KeyEnter:
+		if len(a.ids) > 0 {
+			a.openStory(a.ids[a.cursor])
+		}
+	case "o":
+		if len(a.ids) > 0 {
+			item, err := a.item(a.ids[a.cursor])
+			if err != nil {
+				a.status = fmt.Sprintf("error: %v", err)
+				return
+			}
+			a.openURL(item.Url, item.Id)
+		}
+	}
+}
+
+func (a *App) openStory(id int) {
+	story, err := a.builder.Build(id)
+	if err != nil {
+		a.status = fmt.Sprintf("error: %v", err)
+		return
+	}
+	a.story = newStoryView(story)
+}
+
+// openURL opens url, or the discussion of the item when there is none.
+func (a *App) openURL(url string, id int) {
+	if url == "" {
+		url = fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
+	}
+	if err := a.open(url); err != nil {
+		a.status = fmt.Sprintf("error: %v", err)
+		return
+	}
+	a.status = "opened " + url
+}
+
+func (a *App) editSearch(k Key) {
+	switch {
+	case k == KeyEnter:
+		a.searching = false
+		a.search(a.query)
+	case k == KeyEsc:
+		a.searching, a.query = false, ""
+	case k == KeyBackspace:
+		if r := []rune(a.query); len(r) > 0 {
+			a.query = string(r[:len(r)-1])
+		}
+	case k.printable():
+		a.query += string(k)
+	}
+}
+
+// search moves the cursor to the next row containing query, wrapping around.
+func (a *App) search(query string) {
+	if query == "" {
+		return
+	}
+	query = strings.ToLower(query)
+
+	var n, cursor int
+	var text func(i int) string
+	if a.story != nil {
+		n, cursor = len(a.story.rows), a.story.cursor
+		text = func(i int) string { return a.story.rows[i].searchText() }
+	} else {
+		n, cursor = len(a.ids), a.cursor
+		text = func(i int) string {
+			item, err := a.item(a.ids[i])
+			if err != nil {
+				return ""
+			}
+			return markup.Clean(item.Title + " " + item.Author)
+		}
+	}
+
+	for step := 1; step <= n; step++ {
+		i := (cursor + step) % n
+		if strings.Contains(strings.ToLower(text(i)), query) {
+			if a.story != nil {
+				a.story.cursor = i
+			} else {
+				a.cursor = i
+			}
+			return
+		}
+	}
+	a.status = fmt.Sprintf("not found: %s", query)
+}
+
+// bodyHeight is the number of rows between the title and status lines.
+func (a *App) bodyHeight() int {
+	return a.height - 2
+}
+
+// View renders the screen: a title line, the body and a status line.
+func (a *App) View() string {
+	var lines []string
+	if a.story != nil {
+		lines = a.story.view(a.width, a.bodyHeight())
+	} else {
+		lines = a.listView()
+	}
+
+	title := a.tabs()
+	if a.story != nil {
+		title = markup.Clean(a.story.story.Title)
+	}
+	screen := []string{fit(title, a.width)}
+	for i := 0; i < a.bodyHeight(); i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		screen = append(screen, fit(line, a.width))
+	}
+	screen = append(screen, fit(a.statusLine(), a.width))
+
+	return strings.Join(screen, "\n") + "\n"
+}
+
+func (a *App) tabs() string {
+	var tabs []string
+	for i, list := range Lists {
+		if i == a.list {
+			tabs = append(tabs, "["+string(list)+"]")
+		} else {
+			tabs = append(tabs, " "+string(list)+" ")
+		}
+	}
+
+	return "quartz " + strings.Join(tabs, "")
+}
+
+func (a *App) statusLine() string {
+	switch {
+	case a.searching:
+		return "/" + a.query
+	case a.status != "":
+		return a.status
+	case a.story != nil:
+		return "j/k move  enter expand  o open  / search  q back"
+	}
+
+	return "j/k move  enter open  o open url  tab list  / search  q quit"
+}
+
+func (a *App) listView() []string {
+	height := a.bodyHeight()
+	a.offset = scroll(a.offset, a.cursor, height)
+
+	var lines []string
+	for i := a.offset; i < len(a.ids) && i < a.offset+height; i++ {
+		marker := "  "
+		if i == a.cursor {
+			marker = "> "
+		}
+		item, err := a.item(a.ids[i])
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s%3d. [%d: %v]", marker, i+1, a.ids[i], err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s%3d. %s (%d points by %s, %d comments)",
+			marker, i+1, markup.Clean(item.Title), item.Score, markup.Clean(item.Author), item.Descendants))
+	}
+
+	return lines
+}
+
+// scroll returns the first visible row so that cursor is within height rows.
+func scroll(offset, cursor, height int) int {
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+height {
+		return cursor - height + 1
+	}
+
+	return offset
+}
+
+// clamp keeps i a valid index of n rows.
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+
+	return i
+}
+
+// fit cuts s to width runes.
+func fit(s string, width int) string {
+	s = strings.TrimRight(s, " ")
+	if r := []rune(s); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+
+	return s
+}
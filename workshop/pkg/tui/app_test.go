@@ -0,0 +1,182 @@
+package tui_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/tui"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// api stands in for Hacker News with two stories and a short thread.
+func api(t *testing.T) *hn.HackerNewsClient {
+	responses := map[string]string{
+		"/topstories.json":  "[1,3]",
+		"/newstories.json":  "[3]",
+		"/beststories.json": "[6]",
+		"/item/1.json":      `{"id":1,"type":"story","by":"pg","title":"Y Combinator","score":57,"kids":[2,5],"descendants":3}`,
+		"/item/2.json":      `{"id":2,"type":"comment","by":"sama","parent":1,"text":"Nice.","kids":[4]}`,
+		"/item/4.json":      `{"id":4,"type":"comment","by":"pg","parent":2,"text":"Thanks &amp; welcome."}`,
+		"/item/5.json":      `{"id":5,"type":"comment","by":"jl","parent":1,"text":"Good luck<p>with the batch"}`,
+		"/item/6.json":      `{"id":6,"type":"story","by":"pg","title":"Y Combinator\u001b[2J","score":57,"descendants":3}`,
+		"/item/3.json":      `{"id":3,"type":"story","by":"sama","title":"Startups","score":12,"url":"http://example.com/startups"}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+
+	return hn.NewHTTPClientFor(ts.URL)
+}
+
+func press(app *tui.App, keys ...tui.Key) {
+	for _, k := range keys {
+		app.HandleKey(k)
+	}
+}
+
+func TestApp(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		app := tui.New(api(t), tui.WithSize(60, 5))
+		assert.Equal(t, "quartz [top] new  best\n"+
+			">   1. Y Combinator (57 points by pg, 3 comments)\n"+
+			"    2. Startups (12 points by sama, 0 comments)\n"+
+			"\n"+
+			"j/k move  enter open  o open url  tab list  / search  q quit\n", app.View())
+
+		press(app, tui.KeyTab)
+		assert.Contains(t, app.View(), "quartz  top [new] best\n>   1. Startups")
+
+		press(app, "h", "q")
+		assert.True(t, app.Done())
+	})
+
+	t.Run("control characters", func(t *testing.T) {
+		app := tui.New(api(t), tui.WithSize(60, 5))
+		press(app, tui.KeyTab, tui.KeyTab)
+		assert.NotContains(t, app.View(), "\x1b")
+		assert.Contains(t, app.View(), ">   1. Y Combinator[2J (57 points by pg, 3 comments)")
+	})
+
+	t.Run("open url", func(t *testing.T) {
+		var opened []string
+		app := tui.New(api(t), tui.WithOpener(func(url string) error {
+			opened = append(opened, url)
+			return nil
+		}))
+
+		press(app, "o", "j", "o")
+		assert.Equal(t, []string{"https://news.ycombinator.com/item?id=1", "http://example.com/startups"}, opened)
+		assert.Contains(t, app.View(), "opened http://example.com/startups")
+	})
+
+	t.Run("lazy comment tree", func(t *testing.T) {
+		app := tui.New(api(t), tui.WithSize(40, 12))
+
+		press(app, tui.KeyEnter)
+		assert.Equal(t, "Y Combinator\n"+
+			"57 points by pg | 3 comments\n"+
+			"> ▸ sama: Nice.\n"+
+			"  · jl: Good luck with the batch\n"+
+			"\n\n\n"+
+			"────────────────────────────────────────\n"+
+			"sama:\n"+
+			"Nice.\n"+
+			"\n"+
+			"j/k move  enter expand  o open  / searc…\n", app.View())
+
+		press(app, tui.KeyEnter)
+		assert.Contains(t, app.View(), "> ▾ sama: Nice.\n    · pg: Thanks & welcome.\n  · jl:")
+
+		press(app, tui.KeyDown, tui.KeyEnter, tui.KeyUp, tui.KeyEnter)
+		assert.Contains(t, app.View(), "> ▸ sama: Nice.\n  · jl:")
+
+		press(app, tui.KeyEsc)
+		assert.Contains(t, app.View(), ">   1. Y Combinator")
+	})
+
+	t.Run("search", func(t *testing.T) {
+		app := tui.New(api(t), tui.WithSize(60, 12))
+		press(app, "/", "s", "t", "a", "r")
+		assert.Contains(t, app.View(), "\n/star\n")
+
+		press(app, tui.KeyEnter)
+		assert.Contains(t, app.View(), ">   2. Startups")
+
+		press(app, "g", tui.KeyEnter, "/", "b", "a", "t", "c", "h", tui.KeyEnter)
+		assert.Contains(t, app.View(), "> · jl: Good luck")
+
+		press(app, "/", "x", "y", "z", tui.KeyEnter)
+		assert.Contains(t, app.View(), "not found: xyz")
+	})
+
+	t.Run("API errors", func(t *testing.T) {
+		app := tui.New(hn.NewHTTPClientFor("http://127.0.0.1:1"), tui.WithSize(60, 5))
+		assert.Contains(t, app.View(), "error:")
+		press(app, tui.KeyEnter)
+		assert.False(t, app.Done())
+	})
+
+	t.Run("run", func(t *testing.T) {
+		app := tui.New(api(t), tui.WithSize(60, 5))
+
+		var out bytes.Buffer
+		err := app.Run(strings.NewReader("j\rq"), &out)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, strings.Count(out.String(), "\x1b[H\x1b[2J"))
+		assert.Contains(t, out.String(), "Startups\r\n12 points by sama")
+		assert.False(t, app.Done(), "q only closed the story")
+	})
+}
+
+func TestOpenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+
+	t.Run("command with arguments", func(t *testing.T) {
+		dir := t.TempDir()
+		out := filepath.Join(dir, "args")
+		script := filepath.Join(dir, "browser")
+		assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > "+out+".tmp && mv "+out+".tmp "+out+"\n"), 0755))
+
+		assert.NoError(t, tui.OpenCommand(script+" --new-window")("https://example.com/"))
+		var args []byte
+		for i := 0; i < 500; i++ {
+			if data, err := ioutil.ReadFile(out); err == nil {
+				args = data
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, "--new-window https://example.com/\n", string(args))
+	})
+
+	t.Run("$BROWSER", func(t *testing.T) {
+		old, ok := os.LookupEnv("BROWSER")
+		defer func() {
+			if ok {
+				os.Setenv("BROWSER", old)
+			} else {
+				os.Unsetenv("BROWSER")
+			}
+		}()
+		os.Setenv("BROWSER", "  ")
+
+		assert.Error(t, tui.OpenCommand("")("https://example.com/"))
+	})
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"bufio"
+	"unicode/utf8"
+)
+
+// Key is a key press: a single printable character, or the name of a
+// special key.
+type Key string
+
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyLeft      Key = "left"
+	KeyRight     Key = "right"
+	KeyPageUp    Key = "pgup"
+	KeyPageDown  Key = "pgdown"
+	KeyHome      Key = "home"
+	KeyEnd       Key = "end"
+	KeyEnter     Key = "enter"
+	KeyTab       Key = "tab"
+	KeyBackspace Key = "backspace"
+	KeyEsc       Key = "esc"
+	KeyCtrlC     Key = "ctrl+c"
+)
+
+// escapes maps the CSI sequences of a terminal in raw mode to keys.
+var escapes = map[string]Key{
+	"A":  KeyUp,
+	"B":  KeyDown,
+	"C":  KeyRight,
+	"D":  KeyLeft,
+	"H":  KeyHome,
+	"F":  KeyEnd,
+	"1~": KeyHome,
+	"4~": KeyEnd,
+	"5~": KeyPageUp,
+	"6~": KeyPageDown,
+}
+
+// ReadKey reads one key press from a terminal in raw mode. An escape byte
+// with nothing buffered after it is the escape key itself.
+func ReadKey(r *bufio.Reader) (Key, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return KeyEnter, nil
+	case '\t':
+		return KeyTab, nil
+	case 0x7f, 0x08:
+		return KeyBackspace, nil
+	case 0x03:
+		return KeyCtrlC, nil
+	case 0x1b:
+		return readEscape(r)
+	}
+
+	return Key(string(c)), nil
+}
+
+func readEscape(r *bufio.Reader) (Key, error) {
+	if r.Buffered() == 0 {
+		return KeyEsc, nil
+	}
+	if next, _ := r.Peek(1); next[0] != '[' && next[0] != 'O' {
+		return KeyEsc, nil
+	}
+	r.ReadByte()
+
+	var seq []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return KeyEsc, nil
+		}
+		seq = append(seq, b)
+		// A CSI sequence ends with a byte in the range @ to ~.
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	if key, ok := escapes[string(seq)]; ok {
+		return key, nil
+	}
+
+	return KeyEsc, nil
+}
+
+// printable reports whether k is a single character that can be typed into
+// the search prompt.
+func (k Key) printable() bool {
+	return utf8.RuneCountInString(string(k)) == 1 && k[0] >= 0x20
+}
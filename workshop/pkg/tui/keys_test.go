@@ -0,0 +1,27 @@
+package tui_test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"workshop-starter/pkg/tui"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("j\r\x1b[A\x1b[6~\x1bOB\x7f\tö\x03\x1b"))
+
+	var keys []tui.Key
+	for {
+		k, err := tui.ReadKey(r)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		keys = append(keys, k)
+	}
+
+	assert.Equal(t, []tui.Key{"j", tui.KeyEnter, tui.KeyUp, tui.KeyPageDown, tui.KeyDown, tui.KeyBackspace, tui.KeyTab, "ö", tui.KeyCtrlC, tui.KeyEsc}, keys)
+}
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"workshop-starter/pkg/hn"
+	"workshop-starter/pkg/hn/markup"
+)
+
+// storyView is an open story: a collapsible comment tree above a pane with
+// the full text of the selected comment.
+type storyView struct {
+	story     hn.Story
+	collapsed map[int]bool
+	rows      []row
+	cursor    int
+	offset    int
+}
+
+// row is a visible comment. The pointer is into story and is only valid
+// until the tree changes, when rows are rebuilt.
+type row struct {
+	comment *hn.Comment
+	depth   int
+}
+
+func newStoryView(story hn.Story) *storyView {
+	v := &storyView{story: story, collapsed: map[int]bool{}}
+	v.layout()
+
+	return v
+}
+
+// layout lists the comments that are not hidden in a collapsed subtree.
+func (v *storyView) layout() {
+	v.rows = nil
+	v.story.Walk(func(c *hn.Comment, depth int) error {
+		v.rows = append(v.rows, row{comment: c, depth: depth})
+		if v.collapsed[c.Id] {
+			return hn.SkipSubtree
+		}
+		return nil
+	})
+	v.cursor = clamp(v.cursor, len(v.rows))
+}
+
+func (a *App) storyKey(k Key) {
+	v := a.story
+	switch k {
+	case "q", KeyEsc, "h", KeyLeft:
+		a.story = nil
+	case "j", KeyDown:
+		v.cursor = clamp(v.cursor+1, len(v.rows))
+	case "k", KeyUp:
+		v.cursor = clamp(v.cursor-1, len(v.rows))
+	case KeyPageDown:
+		v.cursor = clamp(v.cursor+a.bodyHeight()/2, len(v.rows))
+	case KeyPageUp:
+		v.cursor = clamp(v.cursor-a.bodyHeight()/2, len(v.rows))
+	case "g", KeyHome:
+		v.cursor = 0
+	case "G", KeyEnd:
+		v.cursor = clamp(len(v.rows)-1, len(v.rows))
+	case KeyEnter, " ", "l", KeyRight:
+		a.toggle()
+	case "o":
+		a.openURL(v.story.Url, v.story.Id)
+	case "c":
+		if len(v.rows) > 0 {
+			a.openURL("", v.rows[v.cursor].comment.Id)
+		}
+	}
+}
+
+// toggle collapses or expands the selected comment. Replies that were not
+// loaded yet are fetched first.
+func (a *App) toggle() {
+	v := a.story
+	if len(v.rows) == 0 {
+		return
+	}
+	c := v.rows[v.cursor].comment
+	switch {
+	case len(c.More) > 0:
+		if _, err := a.builder.Expand(&v.story, c.Id); err != nil {
+			a.status = fmt.Sprintf("error: %v", err)
+			return
+		}
+		v.collapsed[c.Id] = false
+	case len(c.ChildComments) > 0:
+		v.collapsed[c.Id] = !v.collapsed[c.Id]
+	default:
+		return
+	}
+	v.layout()
+}
+
+// marker shows whether a comment has replies and whether they are shown.
+func (v *storyView) marker(c *hn.Comment) string {
+	switch {
+	case len(c.More) > 0 || len(c.ChildComments) > 0 && v.collapsed[c.Id]:
+		return "▸"
+	case len(c.ChildComments) > 0:
+		return "▾"
+	}
+
+	return "·"
+}
+
+func (r row) author() string {
+	if r.comment.Author == "" {
+		return hn.DefaultPlaceholder(*r.comment)
+	}
+
+	return markup.Clean(r.comment.Author)
+}
+
+func (r row) text() string {
+	return strings.Join(strings.Fields(strings.Join(hn.PlainText(r.comment.Text, 1<<20), " ")), " ")
+}
+
+func (r row) searchText() string {
+	return r.author() + " " + r.text()
+}
+
+// view renders the info line, the tree and the detail pane.
+func (v *storyView) view(width, height int) []string {
+	s := v.story
+	info := fmt.Sprintf("%d points by %s | %d comments", s.Score, markup.Clean(s.Author), s.Descendants)
+	if s.Url != "" {
+		info += " | " + markup.Clean(s.Url)
+	}
+	lines := []string{info}
+
+	detail := 0
+	if height >= 10 {
+		detail = height / 3
+	}
+	tree := height - 1 - detail
+	if detail > 0 {
+		tree--
+	}
+
+	v.offset = scroll(v.offset, v.cursor, tree)
+	for i := v.offset; i < len(v.rows) && i < v.offset+tree; i++ {
+		r := v.rows[i]
+		cursor := "  "
+		if i == v.cursor {
+			cursor = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%s%s %s: %s",
+			cursor, strings.Repeat("  ", r.depth-1), v.marker(r.comment), r.author(), r.text()))
+	}
+	if len(v.rows) == 0 {
+		lines = append(lines, "  no comments")
+	}
+	if detail == 0 || len(v.rows) == 0 {
+		return lines
+	}
+
+	for len(lines) < 1+tree {
+		lines = append(lines, "")
+	}
+	lines = append(lines, strings.Repeat("─", width))
+	r := v.rows[v.cursor]
+	text := append([]string{r.author() + ":"}, hn.PlainText(r.comment.Text, width)...)
+	if len(text) > detail {
+		text = text[:detail]
+	}
+
+	return append(lines, text...)
+}